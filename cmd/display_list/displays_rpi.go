@@ -0,0 +1,52 @@
+// +build rpi
+
+/*
+	Go Language Raspberry Pi Interface
+	(c) Copyright David Thorpe 2016-2019
+	All Rights Reserved
+	Documentation http://djthorpe.github.io/gopi/
+	For Licensing and Usage information, please see LICENSE.md
+*/
+
+package main
+
+import (
+	"errors"
+
+	// Frameworks
+	"github.com/djthorpe/gopi"
+
+	// Modules
+	display "github.com/djthorpe/gopi-graphics/sys/display"
+	_ "github.com/djthorpe/gopi-hw/sys/hw"
+	_ "github.com/djthorpe/gopi-hw/sys/metrics"
+)
+
+////////////////////////////////////////////////////////////////////////////////
+
+// AppConfig returns the configuration, which loads the hardware instance
+func AppConfig() gopi.AppConfig {
+	return gopi.NewAppConfig("hw")
+}
+
+// EnumerateDisplays opens each hardware display in turn
+func EnumerateDisplays(app *gopi.AppInstance, callback func(n uint, display gopi.Display) error) error {
+	if app.Hardware == nil || app.Hardware.NumberOfDisplays() == 0 {
+		return errors.New("No displays detected")
+	}
+	for n := uint(0); n < app.Hardware.NumberOfDisplays(); n++ {
+		if module, err := gopi.Open(display.Display{Display: n}, app.Logger); err != nil {
+			return err
+		} else if display, ok := module.(gopi.Display); !ok {
+			module.Close()
+			return gopi.ErrAppError
+		} else {
+			err := callback(n, display)
+			module.Close()
+			if err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
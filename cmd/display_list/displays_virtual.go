@@ -0,0 +1,36 @@
+// +build !rpi
+
+/*
+	Go Language Raspberry Pi Interface
+	(c) Copyright David Thorpe 2016-2019
+	All Rights Reserved
+	Documentation http://djthorpe.github.io/gopi/
+	For Licensing and Usage information, please see LICENSE.md
+*/
+
+package main
+
+import (
+	"errors"
+
+	// Frameworks
+	"github.com/djthorpe/gopi"
+
+	// Modules
+	_ "github.com/djthorpe/gopi-graphics/sys/display"
+)
+
+////////////////////////////////////////////////////////////////////////////////
+
+// AppConfig returns the configuration, which loads the virtual display
+func AppConfig() gopi.AppConfig {
+	return gopi.NewAppConfig("display")
+}
+
+// EnumerateDisplays returns the virtual display
+func EnumerateDisplays(app *gopi.AppInstance, callback func(n uint, display gopi.Display) error) error {
+	if app.Display == nil {
+		return errors.New("No displays detected")
+	}
+	return callback(app.Display.Display(), app.Display)
+}
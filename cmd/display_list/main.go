@@ -6,11 +6,10 @@
 	For Licensing and Usage information, please see LICENSE.md
 */
 
-// Outputs a table of displays - works on RPi at the moment
+// Outputs a table of displays, or the virtual display when not on RPi
 package main
 
 import (
-	"errors"
 	"fmt"
 	"os"
 
@@ -19,42 +18,30 @@ import (
 	"github.com/olekukonko/tablewriter"
 
 	// Modules
-	display "github.com/djthorpe/gopi-graphics/sys/display"
-	_ "github.com/djthorpe/gopi-hw/sys/hw"
-	_ "github.com/djthorpe/gopi-hw/sys/metrics"
 	_ "github.com/djthorpe/gopi/sys/logger"
 )
 
 ////////////////////////////////////////////////////////////////////////////////
 
 func mainLoop(app *gopi.AppInstance, done chan<- struct{}) error {
-	if app.Hardware == nil || app.Hardware.NumberOfDisplays() == 0 {
-		return errors.New("No displays detected")
-	}
-
 	table := tablewriter.NewWriter(os.Stdout)
 	table.SetHeader([]string{"Display", "Name", "Width", "height", "Pixels per inch"})
-	for n := uint(0); n < app.Hardware.NumberOfDisplays(); n++ {
-		if module, err := gopi.Open(display.Display{Display: n}, app.Logger); err != nil {
-			return err
-		} else if display, ok := module.(gopi.Display); !ok {
-			module.Close()
-			return err
-		} else {
-			w, h := display.Size()
-			ppi := fmt.Sprint(display.PixelsPerInch())
-			if ppi == "0" {
-				ppi = "-"
-			}
-			table.Append([]string{
-				fmt.Sprint(n),
-				fmt.Sprint(display.Name()),
-				fmt.Sprint(w),
-				fmt.Sprint(h),
-				fmt.Sprint(ppi),
-			})
-			module.Close()
+	if err := EnumerateDisplays(app, func(n uint, display gopi.Display) error {
+		w, h := display.Size()
+		ppi := fmt.Sprint(display.PixelsPerInch())
+		if ppi == "0" {
+			ppi = "-"
 		}
+		table.Append([]string{
+			fmt.Sprint(n),
+			fmt.Sprint(display.Name()),
+			fmt.Sprint(w),
+			fmt.Sprint(h),
+			fmt.Sprint(ppi),
+		})
+		return nil
+	}); err != nil {
+		return err
 	}
 	table.Render()
 
@@ -62,8 +49,8 @@ func mainLoop(app *gopi.AppInstance, done chan<- struct{}) error {
 }
 
 func main() {
-	// Create the configuration, load the gpio instance
-	config := gopi.NewAppConfig("hw")
+	// Create the configuration
+	config := AppConfig()
 
 	// Run the command line tool
 	os.Exit(gopi.CommandLineTool(config, mainLoop))
@@ -0,0 +1,77 @@
+/*
+	Go Language Raspberry Pi Interface
+	(c) Copyright David Thorpe 2016-2019
+	All Rights Reserved
+	Documentation http://djthorpe.github.io/gopi/
+	For Licensing and Usage information, please see LICENSE.md
+*/
+
+// Saves a snapshot of a display as a PNG image
+package main
+
+import (
+	"fmt"
+	"os"
+
+	// Frameworks
+	"github.com/djthorpe/gopi"
+	"github.com/djthorpe/gopi-graphics/sys/surface"
+
+	// Modules
+	_ "github.com/djthorpe/gopi-graphics/sys/display"
+	_ "github.com/djthorpe/gopi/sys/logger"
+)
+
+////////////////////////////////////////////////////////////////////////////////
+
+func mainLoop(app *gopi.AppInstance, done chan<- struct{}) error {
+	gfx := app.Graphics
+	if gfx == nil {
+		return fmt.Errorf("Missing Surfaces Manager")
+	}
+	writer, ok := gfx.(surface.BitmapWriter)
+	if ok == false {
+		return fmt.Errorf("Surfaces Manager cannot write bitmaps")
+	}
+	path, _ := app.AppFlags.GetString("out")
+
+	// Take the snapshot
+	snapshot, err := gfx.CreateSnapshot(gopi.SURFACE_FLAG_RGB888)
+	if err != nil {
+		return err
+	}
+	defer gfx.DestroyBitmap(snapshot)
+
+	// Write the snapshot to a file
+	if fh, err := os.Create(path); err != nil {
+		return err
+	} else if err := writer.WritePNG(fh, snapshot); err != nil {
+		fh.Close()
+		return err
+	} else if err := fh.Close(); err != nil {
+		return err
+	}
+
+	// Success
+	fmt.Printf("Saved display %v to %v\n", gfx.Display().Display(), path)
+	return nil
+}
+
+func main() {
+	// Create the configuration
+	config := gopi.NewAppConfig("graphics")
+
+	// Set the output path
+	config.AppFlags.FlagString("out", "screenshot.png", "Output PNG file")
+
+	// The display is chosen with the -display flag of the display module
+	config.AppFlags.SetUsageFunc(func(flags *gopi.Flags) {
+		fmt.Fprintf(os.Stderr, "Usage: %v [-display <number>] [-out <path>]\n\n", flags.Name())
+		fmt.Fprintf(os.Stderr, "Saves a snapshot of a display as a PNG image. The display\n")
+		fmt.Fprintf(os.Stderr, "number defaults to zero and the path to screenshot.png.\n\n")
+		flags.PrintDefaults()
+	})
+
+	// Run the command line tool
+	os.Exit(gopi.CommandLineTool(config, mainLoop))
+}
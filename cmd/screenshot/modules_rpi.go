@@ -0,0 +1,16 @@
+// +build rpi
+
+/*
+	Go Language Raspberry Pi Interface
+	(c) Copyright David Thorpe 2016-2019
+	All Rights Reserved
+	Documentation http://djthorpe.github.io/gopi/
+	For Licensing and Usage information, please see LICENSE.md
+*/
+
+package main
+
+import (
+	// Modules
+	_ "github.com/djthorpe/gopi-hw/sys/hw"
+)
@@ -45,6 +45,10 @@ func Main(app *gopi.AppInstance, done chan<- struct{}) error {
 		return fmt.Errorf("Invalid graphics/sprites component")
 	} else if err := sprites.OpenSpritesAtPath(path, FilterFiles); err != nil {
 		return err
+	} else {
+		for _, sprite := range sprites.Sprites("") {
+			fmt.Println(sprite)
+		}
 	}
 
 	fmt.Println("Waiting for CTRL+C")
@@ -18,8 +18,9 @@ import (
 	"github.com/djthorpe/gopi"
 
 	// Modules
+	animation "github.com/djthorpe/gopi-graphics/sys/animation"
 	_ "github.com/djthorpe/gopi-graphics/sys/display"
-	_ "github.com/djthorpe/gopi-graphics/sys/surface"
+	surface "github.com/djthorpe/gopi-graphics/sys/surface"
 	_ "github.com/djthorpe/gopi-hw/sys/hw"
 	_ "github.com/djthorpe/gopi-hw/sys/metrics"
 	_ "github.com/djthorpe/gopi/sys/logger"
@@ -27,11 +28,31 @@ import (
 
 ////////////////////////////////////////////////////////////////////////////////
 
+// Bounce a surface between two points until the animation is cancelled
+func Bounce(animator animation.Animations, s gopi.Surface, from, to gopi.Point) error {
+	_, err := animator.Animate(s, animation.Tween{
+		Properties: animation.PROPERTY_ORIGIN,
+		Origin:     to,
+		Duration:   2 * time.Second,
+		Easing:     surface.EaseInOut,
+		Done: func(_ animation.Animation, finished bool) {
+			if finished {
+				Bounce(animator, s, to, from)
+			}
+		},
+	})
+	return err
+}
+
 func Background(app *gopi.AppInstance, start chan<- struct{}, stop <-chan struct{}) error {
 	gfx := app.Graphics
 	if gfx == nil {
 		return fmt.Errorf("Missing Surfaces Manager")
 	}
+	animator, ok := app.ModuleInstance("graphics/animation").(animation.Animations)
+	if ok == false {
+		return fmt.Errorf("Missing Animation Module")
+	}
 
 	var surface1, surface2, surface3 gopi.Surface
 
@@ -72,21 +93,19 @@ func Background(app *gopi.AppInstance, start chan<- struct{}, stop <-chan struct
 	// Now run the program
 	start <- gopi.DONE
 
-	// Move bitmap once per second
-	timer := time.NewTicker(time.Millisecond * 1)
-FOR_LOOP:
-	for {
-		select {
-		case <-timer.C:
-			gfx.Do(func(gfx gopi.SurfaceManager) error {
-				gfx.MoveOriginBy(surface1, gopi.Point{1, 1})
-				gfx.MoveOriginBy(surface2, gopi.Point{-1, -1})
-				gfx.MoveOriginBy(surface3, gopi.Point{-2, 2})
-				return nil
-			})
-		case <-stop:
-			break FOR_LOOP
-		}
+	// Bounce the surfaces until stopped
+	if err := Bounce(animator, surface1, surface1.Origin(), gopi.Point{450, 450}); err != nil {
+		return err
+	} else if err := Bounce(animator, surface2, surface2.Origin(), gopi.Point{0, 0}); err != nil {
+		return err
+	} else if err := Bounce(animator, surface3, surface3.Origin(), gopi.Point{-150, 450}); err != nil {
+		return err
+	}
+	<-stop
+
+	// Cancel the animations
+	for _, s := range []gopi.Surface{surface1, surface2, surface3} {
+		animator.CancelAll(s)
 	}
 
 	// Finished
@@ -102,7 +121,7 @@ func Main(app *gopi.AppInstance, done chan<- struct{}) error {
 
 func main() {
 	// Create the configuration
-	config := gopi.NewAppConfig("graphics")
+	config := gopi.NewAppConfig("graphics", "graphics/animation")
 
 	// Run the command line tool
 	os.Exit(gopi.CommandLineTool2(config, Main, Background))
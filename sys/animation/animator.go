@@ -0,0 +1,495 @@
+/*
+  Go Language Raspberry Pi Interface
+  (c) Copyright David Thorpe 2019
+  All Rights Reserved
+
+  Documentation http://djthorpe.github.io/gopi/
+  For Licensing and Usage information, please see LICENSE.md
+*/
+
+package animation
+
+import (
+	"fmt"
+	"math"
+	"strings"
+	"sync"
+	"time"
+
+	// Frameworks
+	gopi "github.com/djthorpe/gopi"
+	surface "github.com/djthorpe/gopi-graphics/sys/surface"
+)
+
+////////////////////////////////////////////////////////////////////////////////
+// TYPES
+
+type Animator struct {
+	Graphics gopi.SurfaceManager
+
+	// Maximum number of frames per second, or zero for the default
+	FPS uint
+}
+
+type animator struct {
+	log       gopi.Logger
+	graphics  gopi.SurfaceManager
+	interval  time.Duration
+	animating []*animation
+
+	// Frame callback when the surface manager calls a callback for
+	// each refresh of the display, and the time of the last frame
+	scheduler surface.FrameScheduler
+	callback  surface.FrameCallbackId
+	last      time.Time
+
+	// Channels to stop the goroutine which applies animations
+	// when there are no frame callbacks
+	stop    chan struct{}
+	stopped chan struct{}
+
+	sync.Mutex
+}
+
+// Animations is implemented by the animation module. The changes
+// to all surfaces for a frame are made within a single update
+type Animations interface {
+	gopi.Driver
+
+	// Start animating properties of a surface, replacing animations in
+	// progress for the same properties of the surface
+	Animate(s gopi.Surface, tween Tween) (Animation, error)
+
+	// Cancel all animations for a surface
+	CancelAll(s gopi.Surface)
+}
+
+// Animation is an animation in progress
+type Animation interface {
+	// Return the surface which is animated
+	Surface() gopi.Surface
+
+	// Return the tween for the animation
+	Tween() Tween
+
+	// Cancel the animation, leaving the properties unchanged
+	Cancel()
+}
+
+// Tween changes properties of a surface from their values when the
+// animation starts to the values in the tween, over a duration
+type Tween struct {
+	Properties Property
+	Origin     gopi.Point
+	Size       gopi.Size
+	Opacity    float32
+	Layer      uint16
+
+	// Duration of the animation, and the easing function which
+	// determines the rate of change, where nil is linear
+	Duration time.Duration
+	Easing   surface.Easing
+
+	// Called when the animation is complete or cancelled, outside
+	// of an update so that other animations can be started
+	Done func(a Animation, finished bool)
+}
+
+// Property is a bitmask of the surface properties which are animated
+type Property uint
+
+type animation struct {
+	animator *animator
+	surface  gopi.Surface
+	tween    Tween
+	start    time.Time
+	from     Tween
+}
+
+////////////////////////////////////////////////////////////////////////////////
+// CONSTANTS
+
+const (
+	PROPERTY_NONE    Property = 0x00
+	PROPERTY_ORIGIN  Property = 0x01
+	PROPERTY_SIZE    Property = 0x02
+	PROPERTY_OPACITY Property = 0x04
+	PROPERTY_LAYER   Property = 0x08
+	PROPERTY_ALL     Property = PROPERTY_ORIGIN | PROPERTY_SIZE | PROPERTY_OPACITY | PROPERTY_LAYER
+)
+
+const (
+	ANIMATION_FPS_DEFAULT = 60
+)
+
+////////////////////////////////////////////////////////////////////////////////
+// OPEN AND CLOSE
+
+func (config Animator) Open(log gopi.Logger) (gopi.Driver, error) {
+	log.Debug("<graphics.animation>Open{ graphics=%v fps=%v }", config.Graphics, config.FPS)
+
+	// Check parameters
+	if config.Graphics == nil {
+		return nil, gopi.ErrBadParameter
+	} else if config.FPS == 0 {
+		config.FPS = ANIMATION_FPS_DEFAULT
+	}
+
+	this := new(animator)
+	this.log = log
+	this.graphics = config.Graphics
+	this.interval = time.Second / time.Duration(config.FPS)
+	this.animating = make([]*animation, 0)
+
+	// Animations are paced to the display when the surface manager
+	// calls a callback for each refresh of the display
+	if scheduler, ok := config.Graphics.(surface.FrameScheduler); ok {
+		this.scheduler = scheduler
+	}
+
+	return this, nil
+}
+
+func (this *animator) Close() error {
+	// Cancel animations and stop the goroutine which applies them
+	this.Lock()
+	this.log.Debug("<graphics.animation>Close{ animating=%v }", len(this.animating))
+	cancelled := this.animating
+	this.animating = nil
+	stop, stopped := this.stop, this.stopped
+	this.stop = nil
+	if this.callback != 0 {
+		if err := this.scheduler.RemoveFrameCallback(this.callback); err != nil {
+			this.log.Warn("<graphics.animation>Close: %v", err)
+		}
+		this.callback = 0
+	}
+
+	// Free resources
+	this.graphics = nil
+	this.Unlock()
+
+	if stop != nil {
+		close(stop)
+		<-stopped
+	}
+	for _, a := range cancelled {
+		a.done(false)
+	}
+
+	// Return success
+	return nil
+}
+
+////////////////////////////////////////////////////////////////////////////////
+// STRINGIFY
+
+func (this *animator) String() string {
+	this.Lock()
+	defer this.Unlock()
+	return fmt.Sprintf("<graphics.animation>{ interval=%v animating=%v }", this.interval, len(this.animating))
+}
+
+func (this *animation) String() string {
+	return fmt.Sprintf("<graphics.animation.Animation>{ surface=%v properties=%v duration=%v }", this.surface, this.tween.Properties, this.tween.Duration)
+}
+
+func (p Property) String() string {
+	if p == PROPERTY_NONE {
+		return "PROPERTY_NONE"
+	}
+	parts := make([]string, 0, 4)
+	for f := PROPERTY_ORIGIN; f <= PROPERTY_LAYER; f <<= 1 {
+		if p&f == 0 {
+			continue
+		}
+		switch f {
+		case PROPERTY_ORIGIN:
+			parts = append(parts, "PROPERTY_ORIGIN")
+		case PROPERTY_SIZE:
+			parts = append(parts, "PROPERTY_SIZE")
+		case PROPERTY_OPACITY:
+			parts = append(parts, "PROPERTY_OPACITY")
+		case PROPERTY_LAYER:
+			parts = append(parts, "PROPERTY_LAYER")
+		}
+	}
+	if p&^PROPERTY_ALL != 0 {
+		parts = append(parts, "[?? Invalid Property value]")
+	}
+	return strings.Join(parts, "|")
+}
+
+////////////////////////////////////////////////////////////////////////////////
+// ANIMATIONS
+
+func (this *animator) Animate(s gopi.Surface, tween Tween) (Animation, error) {
+	this.log.Debug2("<graphics.animation>Animate{ surface=%v properties=%v duration=%v }", s, tween.Properties, tween.Duration)
+
+	if s == nil {
+		return nil, gopi.ErrBadParameter
+	} else if tween.Properties == PROPERTY_NONE || tween.Properties&^PROPERTY_ALL != 0 {
+		return nil, gopi.ErrBadParameter
+	} else if tween.Duration < 0 {
+		return nil, gopi.ErrBadParameter
+	} else if tween.Properties&PROPERTY_OPACITY != 0 && (tween.Opacity < 0.0 || tween.Opacity > 1.0) {
+		return nil, gopi.ErrBadParameter
+	} else if tween.Easing == nil {
+		tween.Easing = surface.EaseLinear
+	}
+
+	this.Lock()
+	if this.graphics == nil {
+		this.Unlock()
+		return nil, gopi.ErrOutOfOrder
+	}
+
+	// Replace animations of the same properties of the surface
+	replaced := this.remove(func(a *animation) bool {
+		return a.surface == s && a.tween.Properties&tween.Properties != 0
+	})
+
+	// Start applying animations if they are not already being applied
+	a := &animation{animator: this, surface: s, tween: tween}
+	this.animating = append(this.animating, a)
+	if this.scheduler != nil {
+		if this.callback == 0 {
+			if callback, err := this.scheduler.AddFrameCallback(this.frame_callback); err != nil {
+				// The replaced animations have already been removed, so
+				// they are cancelled
+				this.animating = this.animating[:len(this.animating)-1]
+				this.Unlock()
+				for _, a := range replaced {
+					a.done(false)
+				}
+				return nil, err
+			} else {
+				this.callback = callback
+			}
+		}
+	} else if this.stop == nil {
+		this.stop, this.stopped = make(chan struct{}), make(chan struct{})
+		go this.run(this.stop, this.stopped)
+	}
+	this.Unlock()
+
+	for _, a := range replaced {
+		a.done(false)
+	}
+
+	// Return success
+	return a, nil
+}
+
+func (this *animator) CancelAll(s gopi.Surface) {
+	this.log.Debug2("<graphics.animation>CancelAll{ surface=%v }", s)
+
+	this.Lock()
+	cancelled := this.remove(func(a *animation) bool {
+		return a.surface == s
+	})
+	this.Unlock()
+
+	for _, a := range cancelled {
+		a.done(false)
+	}
+}
+
+////////////////////////////////////////////////////////////////////////////////
+// ANIMATION
+
+func (this *animation) Surface() gopi.Surface {
+	return this.surface
+}
+
+func (this *animation) Tween() Tween {
+	return this.tween
+}
+
+func (this *animation) Cancel() {
+	this.animator.Lock()
+	cancelled := this.animator.remove(func(a *animation) bool {
+		return a == this
+	})
+	this.animator.Unlock()
+
+	for _, a := range cancelled {
+		a.done(false)
+	}
+}
+
+////////////////////////////////////////////////////////////////////////////////
+// PRIVATE METHODS
+
+// run applies animations each frame until there are no animations in
+// progress or the goroutine is stopped
+func (this *animator) run(stop, stopped chan struct{}) {
+	ticker := time.NewTicker(this.interval)
+	defer close(stopped)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-stop:
+			return
+		case now := <-ticker.C:
+			if this.frame(now) == false {
+				return
+			}
+		}
+	}
+}
+
+// frame changes the properties of all animated surfaces within a single
+// update, and then calls the callbacks for animations which are complete.
+// Returns false when there are no animations in progress
+func (this *animator) frame(now time.Time) bool {
+	this.Lock()
+	if len(this.animating) == 0 {
+		this.stop = nil
+		this.Unlock()
+		return false
+	}
+	var complete []*animation
+	if err := this.graphics.Do(func(gfx gopi.SurfaceManager) error {
+		complete = this.apply(gfx, now)
+		return nil
+	}); err != nil && err != gopi.ErrOutOfOrder {
+		// Frames are skipped when another update is in progress
+		this.log.Warn("<graphics.animation>Frame: %v", err)
+	}
+	this.Unlock()
+
+	for _, a := range complete {
+		a.done(true)
+	}
+	return true
+}
+
+// frame_callback changes the properties of all animated surfaces for a
+// refresh of the display, unless the time since the last frame is less
+// than the frame interval. The callbacks for animations which are complete
+// are called in the background, since the callback is within an update
+func (this *animator) frame_callback(gfx gopi.SurfaceManager, t time.Time) {
+	this.Lock()
+	defer this.Unlock()
+
+	// Allow for jitter in the refresh of the display
+	if this.last.IsZero() == false && t.Sub(this.last) < this.interval*3/4 {
+		return
+	}
+	this.last = t
+
+	// Remove the frame callback when there are no more animations
+	complete := this.apply(gfx, t)
+	if len(this.animating) == 0 && this.callback != 0 {
+		if err := this.scheduler.RemoveFrameCallback(this.callback); err != nil {
+			this.log.Warn("<graphics.animation>Frame: %v", err)
+		}
+		this.callback = 0
+		this.last = time.Time{}
+	}
+	if len(complete) > 0 {
+		go func() {
+			for _, a := range complete {
+				a.done(true)
+			}
+		}()
+	}
+}
+
+// apply changes the properties of all animated surfaces within an
+// update, and removes and returns the animations which are complete
+func (this *animator) apply(gfx gopi.SurfaceManager, now time.Time) []*animation {
+	complete := make(map[*animation]bool, len(this.animating))
+	for _, a := range this.animating {
+		if a.apply(gfx, now) {
+			complete[a] = true
+		}
+	}
+	return this.remove(func(a *animation) bool {
+		return complete[a]
+	})
+}
+
+// remove removes the animations which match a function, and returns them
+func (this *animator) remove(match func(*animation) bool) []*animation {
+	removed := make([]*animation, 0)
+	animating := this.animating[:0]
+	for _, a := range this.animating {
+		if match(a) {
+			removed = append(removed, a)
+		} else {
+			animating = append(animating, a)
+		}
+	}
+	this.animating = animating
+	return removed
+}
+
+// apply changes the properties of the surface for a frame, where the
+// properties the animation starts from are read on the first frame.
+// Returns true when the animation is complete
+func (this *animation) apply(gfx gopi.SurfaceManager, now time.Time) bool {
+	if this.start.IsZero() {
+		this.start = now
+		this.from = Tween{
+			Origin:  this.surface.Origin(),
+			Size:    this.surface.Size(),
+			Opacity: this.surface.Opacity(),
+			Layer:   this.surface.Layer(),
+		}
+	}
+
+	// Determine the progress of the animation
+	t, complete := 1.0, true
+	if this.tween.Duration > 0 {
+		if t = float64(now.Sub(this.start)) / float64(this.tween.Duration); t < 1.0 {
+			complete = false
+		} else {
+			t = 1.0
+		}
+	}
+	p := float32(this.tween.Easing(t))
+
+	// Change the properties
+	from, to := this.from, this.tween
+	if to.Properties&PROPERTY_ORIGIN != 0 {
+		origin := gopi.Point{lerp(from.Origin.X, to.Origin.X, p), lerp(from.Origin.Y, to.Origin.Y, p)}
+		if err := gfx.SetOrigin(this.surface, origin); err != nil {
+			this.animator.log.Warn("<graphics.animation>SetOrigin: %v: %v", this.surface, err)
+		}
+	}
+	if to.Properties&PROPERTY_SIZE != 0 {
+		size := gopi.Size{lerp(from.Size.W, to.Size.W, p), lerp(from.Size.H, to.Size.H, p)}
+		if err := gfx.SetSize(this.surface, size); err != nil {
+			this.animator.log.Warn("<graphics.animation>SetSize: %v: %v", this.surface, err)
+		}
+	}
+	if to.Properties&PROPERTY_OPACITY != 0 {
+		opacity := float32(math.Max(0.0, math.Min(1.0, float64(lerp(from.Opacity, to.Opacity, p)))))
+		if err := gfx.SetOpacity(this.surface, opacity); err != nil {
+			this.animator.log.Warn("<graphics.animation>SetOpacity: %v: %v", this.surface, err)
+		}
+	}
+	if to.Properties&PROPERTY_LAYER != 0 {
+		layer := uint16(math.Round(float64(lerp(float32(from.Layer), float32(to.Layer), p))))
+		if layer != this.surface.Layer() {
+			if err := gfx.SetLayer(this.surface, layer); err != nil {
+				this.animator.log.Warn("<graphics.animation>SetLayer: %v: %v", this.surface, err)
+			}
+		}
+	}
+
+	return complete
+}
+
+// done calls the callback for the animation
+func (this *animation) done(finished bool) {
+	if this.tween.Done != nil {
+		this.tween.Done(this, finished)
+	}
+}
+
+func lerp(from, to, p float32) float32 {
+	return from + (to-from)*p
+}
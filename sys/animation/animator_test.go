@@ -0,0 +1,130 @@
+// +build !rpi
+
+/*
+  Go Language Raspberry Pi Interface
+  (c) Copyright David Thorpe 2019
+  All Rights Reserved
+
+  Documentation http://djthorpe.github.io/gopi/
+  For Licensing and Usage information, please see LICENSE.md
+*/
+
+package animation_test
+
+import (
+	"testing"
+	"time"
+
+	// Frameworks
+	gopi "github.com/djthorpe/gopi"
+	animation "github.com/djthorpe/gopi-graphics/sys/animation"
+	display "github.com/djthorpe/gopi-graphics/sys/display"
+	logger "github.com/djthorpe/gopi-graphics/sys/internal/logger"
+	surface "github.com/djthorpe/gopi-graphics/sys/surface"
+)
+
+////////////////////////////////////////////////////////////////////////////////
+// TESTS
+
+func Test_Property_000(t *testing.T) {
+	if str := (animation.PROPERTY_ORIGIN | animation.PROPERTY_OPACITY).String(); str != "PROPERTY_ORIGIN|PROPERTY_OPACITY" {
+		t.Error("Unexpected string", str)
+	}
+}
+
+func Test_Animate_000(t *testing.T) {
+	// Animate origin and opacity, and wait for completion
+	gfx, animator := open_animator(t)
+	defer gfx.Close()
+	defer animator.Close()
+	s := create_surface(t, gfx)
+
+	done := make(chan bool)
+	if _, err := animator.Animate(s, animation.Tween{
+		Properties: animation.PROPERTY_ORIGIN | animation.PROPERTY_OPACITY,
+		Origin:     gopi.Point{20, 10},
+		Opacity:    0.5,
+		Duration:   50 * time.Millisecond,
+		Easing:     surface.EaseOut,
+		Done: func(_ animation.Animation, finished bool) {
+			done <- finished
+		},
+	}); err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case finished := <-done:
+		if finished == false {
+			t.Error("Expected animation to finish")
+		} else if origin := s.Origin(); origin != (gopi.Point{20, 10}) {
+			t.Error("Unexpected origin", origin)
+		} else if opacity := s.Opacity(); opacity != 0.5 {
+			t.Error("Unexpected opacity", opacity)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("Timeout waiting for animation")
+	}
+}
+
+func Test_Animate_001(t *testing.T) {
+	// Replace and cancel animations
+	gfx, animator := open_animator(t)
+	defer gfx.Close()
+	defer animator.Close()
+	s := create_surface(t, gfx)
+
+	done := make(chan bool, 2)
+	tween := animation.Tween{
+		Properties: animation.PROPERTY_SIZE,
+		Size:       gopi.Size{30, 30},
+		Duration:   time.Hour,
+		Done: func(_ animation.Animation, finished bool) {
+			done <- finished
+		},
+	}
+	if _, err := animator.Animate(s, animation.Tween{Properties: animation.PROPERTY_OPACITY, Opacity: 2.0}); err != gopi.ErrBadParameter {
+		t.Error("Expected ErrBadParameter, got", err)
+	}
+	if _, err := animator.Animate(s, tween); err != nil {
+		t.Fatal(err)
+	} else if a, err := animator.Animate(s, tween); err != nil {
+		t.Fatal(err)
+	} else if finished := <-done; finished {
+		t.Error("Expected replaced animation to be cancelled")
+	} else {
+		a.Cancel()
+	}
+	if finished := <-done; finished {
+		t.Error("Expected animation to be cancelled")
+	}
+}
+
+////////////////////////////////////////////////////////////////////////////////
+// PRIVATE METHODS
+
+func open_animator(t *testing.T) (gopi.SurfaceManager, animation.Animations) {
+	t.Helper()
+	if d, err := gopi.Open(display.VirtualDisplay{Width: 64, Height: 48}, logger.New(t)); err != nil {
+		t.Fatal(err)
+	} else if gfx, err := gopi.Open(surface.SurfaceManager{Display: d.(gopi.Display)}, logger.New(t)); err != nil {
+		t.Fatal(err)
+	} else if animator, err := gopi.Open(animation.Animator{Graphics: gfx.(gopi.SurfaceManager), FPS: 100}, logger.New(t)); err != nil {
+		t.Fatal(err)
+	} else {
+		return gfx.(gopi.SurfaceManager), animator.(animation.Animations)
+	}
+	return nil, nil
+}
+
+func create_surface(t *testing.T, gfx gopi.SurfaceManager) gopi.Surface {
+	t.Helper()
+	var s gopi.Surface
+	if err := gfx.Do(func(gfx gopi.SurfaceManager) error {
+		var err error
+		s, err = gfx.CreateSurface(gopi.SURFACE_FLAG_BITMAP, 1.0, gopi.SURFACE_LAYER_DEFAULT, gopi.ZeroPoint, gopi.Size{10, 10})
+		return err
+	}); err != nil {
+		t.Fatal(err)
+	}
+	return s
+}
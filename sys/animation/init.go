@@ -0,0 +1,38 @@
+/*
+  Go Language Raspberry Pi Interface
+  (c) Copyright David Thorpe 2019
+  All Rights Reserved
+
+  Documentation http://djthorpe.github.io/gopi/
+  For Licensing and Usage information, please see LICENSE.md
+*/
+
+package animation
+
+import (
+	"github.com/djthorpe/gopi"
+)
+
+////////////////////////////////////////////////////////////////////////////////
+// INIT
+
+func init() {
+	// Register animator
+	gopi.RegisterModule(gopi.Module{
+		Name:     "graphics/animation",
+		Type:     gopi.MODULE_TYPE_OTHER,
+		Requires: []string{"graphics"},
+		Config: func(config *gopi.AppConfig) {
+			config.AppFlags.FlagUint("animation.fps", ANIMATION_FPS_DEFAULT, "Maximum animation frames per second")
+		},
+		New: func(app *gopi.AppInstance) (gopi.Driver, error) {
+			config := Animator{
+				Graphics: app.Graphics,
+			}
+			if fps, exists := app.AppFlags.GetUint("animation.fps"); exists {
+				config.FPS = fps
+			}
+			return gopi.Open(config, app.Logger)
+		},
+	})
+}
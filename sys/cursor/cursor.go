@@ -0,0 +1,265 @@
+/*
+  Go Language Raspberry Pi Interface
+  (c) Copyright David Thorpe 2019
+  All Rights Reserved
+
+  Documentation http://djthorpe.github.io/gopi/
+  For Licensing and Usage information, please see LICENSE.md
+*/
+
+package cursor
+
+import (
+	"fmt"
+	"sync"
+
+	// Frameworks
+	gopi "github.com/djthorpe/gopi"
+	sprites "github.com/djthorpe/gopi-graphics/sys/sprites"
+)
+
+////////////////////////////////////////////////////////////////////////////////
+// TYPES
+
+type Cursor struct {
+	Graphics gopi.SurfaceManager
+	Sprites  gopi.SpriteManager
+}
+
+type cursor struct {
+	log      gopi.Logger
+	graphics gopi.SurfaceManager
+	sprites  gopi.SpriteManager
+	surface  gopi.Surface
+
+	// Requested state, where the sprite is looked up by name on
+	// update in case it has been removed or reloaded
+	name     string
+	sprite   gopi.Sprite
+	position gopi.Point
+	visible  bool
+
+	// Set when the sprite has changed since the last update
+	changed bool
+
+	sync.Mutex
+}
+
+// MouseCursor is implemented by the cursor module. Changes to the
+// cursor are applied together when Update is called within the
+// surface manager Do method
+type MouseCursor interface {
+	gopi.Driver
+
+	// Set the cursor shape by sprite name
+	SetCursor(name string) error
+
+	// Return the current cursor shape, or nil
+	Cursor() gopi.Sprite
+
+	// Set and return the position of the cursor hotspot
+	SetPosition(gopi.Point)
+	Position() gopi.Point
+
+	// Show and hide the cursor. The cursor surface is destroyed
+	// when the cursor is hidden, so that it is not composited
+	Show()
+	Hide()
+	Visible() bool
+
+	// Apply changes to the cursor surface, which should be
+	// called within the surface manager Do method
+	Update(gopi.SurfaceManager) error
+}
+
+////////////////////////////////////////////////////////////////////////////////
+// OPEN AND CLOSE
+
+func (config Cursor) Open(log gopi.Logger) (gopi.Driver, error) {
+	log.Debug("<graphics.cursor>Open{ graphics=%v sprites=%v }", config.Graphics, config.Sprites)
+
+	// Check parameters
+	if config.Graphics == nil || config.Sprites == nil {
+		return nil, gopi.ErrBadParameter
+	} else if _, ok := config.Sprites.(sprites.SpriteBitmaps); ok == false {
+		return nil, gopi.ErrBadParameter
+	}
+
+	this := new(cursor)
+	this.log = log
+	this.graphics = config.Graphics
+	this.sprites = config.Sprites
+	this.visible = true
+
+	return this, nil
+}
+
+func (this *cursor) Close() error {
+	this.Lock()
+	defer this.Unlock()
+
+	this.log.Debug("<graphics.cursor>Close{ sprite=%v }", this.sprite)
+
+	// Destroy the cursor surface
+	if this.surface != nil {
+		if err := this.graphics.Do(func(manager gopi.SurfaceManager) error {
+			return manager.DestroySurface(this.surface)
+		}); err != nil {
+			return err
+		}
+	}
+
+	// Free resources
+	this.graphics = nil
+	this.sprites = nil
+	this.surface = nil
+	this.name = ""
+	this.sprite = nil
+
+	// Return success
+	return nil
+}
+
+////////////////////////////////////////////////////////////////////////////////
+// IMPLEMENTATION
+
+func (this *cursor) SetCursor(name string) error {
+	this.log.Debug2("<graphics.cursor>SetCursor{ name=%v }", name)
+
+	this.Lock()
+	defer this.Unlock()
+
+	if this.sprites == nil {
+		return gopi.ErrOutOfOrder
+	} else if found := this.sprites.Sprites(name); len(found) != 1 || found[0].Name() != name {
+		return gopi.ErrBadParameter
+	} else if found[0] != this.sprite {
+		this.name = name
+		this.sprite = found[0]
+		this.changed = true
+	}
+
+	// Success
+	return nil
+}
+
+func (this *cursor) Cursor() gopi.Sprite {
+	this.Lock()
+	defer this.Unlock()
+	return this.sprite
+}
+
+func (this *cursor) SetPosition(position gopi.Point) {
+	this.Lock()
+	defer this.Unlock()
+	this.position = position
+}
+
+func (this *cursor) Position() gopi.Point {
+	this.Lock()
+	defer this.Unlock()
+	return this.position
+}
+
+func (this *cursor) Show() {
+	this.Lock()
+	defer this.Unlock()
+	this.visible = true
+}
+
+func (this *cursor) Hide() {
+	this.Lock()
+	defer this.Unlock()
+	this.visible = false
+}
+
+func (this *cursor) Visible() bool {
+	this.Lock()
+	defer this.Unlock()
+	return this.visible
+}
+
+// Update creates the cursor surface when the cursor is shown and
+// destroys it when the cursor is hidden or the shape has changed,
+// and otherwise moves the surface. The cursor is not drawn while its
+// sprite has been removed, and takes the new shape when reloaded
+func (this *cursor) Update(manager gopi.SurfaceManager) error {
+	this.Lock()
+	defer this.Unlock()
+
+	this.log.Debug2("<graphics.cursor>Update{ sprite=%v position=%v visible=%v }", this.sprite, this.position, this.visible)
+
+	if this.graphics == nil {
+		return gopi.ErrOutOfOrder
+	} else if manager != this.graphics {
+		return gopi.ErrBadParameter
+	}
+
+	// Look up the sprite again, in case it has been removed or reloaded
+	if this.name != "" {
+		var sprite gopi.Sprite
+		if found := this.sprites.Sprites(this.name); len(found) == 1 && found[0].Name() == this.name {
+			sprite = found[0]
+		}
+		if sprite != this.sprite {
+			this.sprite = sprite
+			this.changed = true
+		}
+	}
+
+	// Destroy the surface when the shape has changed or the cursor is hidden
+	if this.surface != nil && (this.changed || this.visible == false) {
+		if err := manager.DestroySurface(this.surface); err != nil {
+			return err
+		}
+		this.surface = nil
+	}
+	this.changed = false
+
+	// Create the surface when the cursor is shown
+	if this.surface == nil {
+		if this.sprite != nil && this.visible {
+			if bitmap, err := this.sprites.(sprites.SpriteBitmaps).BitmapForSprite(this.sprite); err != nil {
+				return err
+			} else if surface, err := manager.CreateSurfaceWithBitmap(bitmap, gopi.SURFACE_FLAG_ALPHA_FROM_SOURCE, 1.0, gopi.SURFACE_LAYER_CURSOR, this.origin(), this.sprite.Size()); err != nil {
+				return err
+			} else {
+				this.surface = surface
+			}
+		}
+		return nil
+	}
+
+	// Move the surface
+	if origin := this.origin(); origin != this.surface.Origin() {
+		if err := manager.SetOrigin(this.surface, origin); err != nil {
+			return err
+		}
+	}
+
+	// Success
+	return nil
+}
+
+////////////////////////////////////////////////////////////////////////////////
+// STRINGIFY
+
+func (this *cursor) String() string {
+	this.Lock()
+	defer this.Unlock()
+	if this.sprite == nil {
+		return fmt.Sprintf("<graphics.cursor>{ sprite=<nil> position=%v visible=%v }", this.position, this.visible)
+	} else {
+		return fmt.Sprintf("<graphics.cursor>{ sprite=%v position=%v visible=%v }", this.sprite.Name(), this.position, this.visible)
+	}
+}
+
+////////////////////////////////////////////////////////////////////////////////
+// PRIVATE METHODS
+
+// origin returns the surface origin which places the sprite
+// hotspot at the cursor position
+func (this *cursor) origin() gopi.Point {
+	hotspot := this.sprite.Hotspot()
+	return gopi.Point{this.position.X - hotspot.X, this.position.Y - hotspot.Y}
+}
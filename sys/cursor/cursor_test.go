@@ -0,0 +1,200 @@
+// +build !rpi
+
+/*
+  Go Language Raspberry Pi Interface
+  (c) Copyright David Thorpe 2019
+  All Rights Reserved
+
+  Documentation http://djthorpe.github.io/gopi/
+  For Licensing and Usage information, please see LICENSE.md
+*/
+
+package cursor_test
+
+import (
+	"image/color"
+	"strings"
+	"testing"
+
+	// Frameworks
+	gopi "github.com/djthorpe/gopi"
+	cursor "github.com/djthorpe/gopi-graphics/sys/cursor"
+	display "github.com/djthorpe/gopi-graphics/sys/display"
+	logger "github.com/djthorpe/gopi-graphics/sys/internal/logger"
+	sprites "github.com/djthorpe/gopi-graphics/sys/sprites"
+	surface "github.com/djthorpe/gopi-graphics/sys/surface"
+)
+
+////////////////////////////////////////////////////////////////////////////////
+// GLOBAL VARIABLES
+
+var (
+	// A cross with the hotspot in the middle
+	cross = `
+[cross]
+.r.
+rRr
+.r.
+
+r = red
+. = transparent
+`
+	red = color.NRGBA{0xFF, 0x00, 0x00, 0xFF}
+)
+
+////////////////////////////////////////////////////////////////////////////////
+// TESTS
+
+func Test_Cursor_000(t *testing.T) {
+	// Show a cursor and move it, so that the hotspot is at the position
+	gfx, _, mouse := open_cursor(t)
+	defer gfx.Close()
+	defer mouse.Close()
+	if err := mouse.SetCursor("cross"); err != nil {
+		t.Fatal(err)
+	} else if err := mouse.SetCursor("pointer"); err != gopi.ErrBadParameter {
+		t.Error("Expected ErrBadParameter, got", err)
+	}
+
+	mouse.SetPosition(gopi.Point{5, 5})
+	update(t, gfx, mouse)
+	expect_cross(t, gfx, 5, 5)
+
+	mouse.SetPosition(gopi.Point{10, 3})
+	update(t, gfx, mouse)
+	expect_cross(t, gfx, 10, 3)
+	if pixel := read_pixel(t, gfx, 5, 5); pixel == red {
+		t.Error("Expected cursor to move from 5,5")
+	}
+}
+
+func Test_Cursor_001(t *testing.T) {
+	// Hide the cursor, which removes the surface, and show it again
+	gfx, _, mouse := open_cursor(t)
+	defer gfx.Close()
+	defer mouse.Close()
+	if err := mouse.SetCursor("cross"); err != nil {
+		t.Fatal(err)
+	}
+	mouse.SetPosition(gopi.Point{5, 5})
+	update(t, gfx, mouse)
+	if surfaces := gfx.(surface.Resources).Surfaces(); len(surfaces) != 1 {
+		t.Fatal("Expected one surface, got", surfaces)
+	} else if surfaces[0].Layer() != gopi.SURFACE_LAYER_CURSOR {
+		t.Error("Unexpected layer", surfaces[0].Layer())
+	}
+
+	mouse.Hide()
+	mouse.SetPosition(gopi.Point{8, 8})
+	update(t, gfx, mouse)
+	if mouse.Visible() {
+		t.Error("Expected cursor to be hidden")
+	} else if surfaces := gfx.(surface.Resources).Surfaces(); len(surfaces) != 0 {
+		t.Error("Expected no surfaces, got", surfaces)
+	} else if pixel := read_pixel(t, gfx, 5, 5); pixel == red {
+		t.Error("Expected cursor to be hidden")
+	}
+
+	mouse.Show()
+	update(t, gfx, mouse)
+	expect_cross(t, gfx, 8, 8)
+}
+
+func Test_Cursor_002(t *testing.T) {
+	// Remove the cursor sprite, which hides the cursor, and then load
+	// a sprite with the same name, which changes the shape
+	gfx, manager, mouse := open_cursor(t)
+	defer gfx.Close()
+	defer mouse.Close()
+	if err := mouse.SetCursor("cross"); err != nil {
+		t.Fatal(err)
+	}
+	mouse.SetPosition(gopi.Point{8, 8})
+	update(t, gfx, mouse)
+	expect_cross(t, gfx, 8, 8)
+
+	// The retired bitmap is the only one, so no bitmap is created for
+	// the removed sprite
+	if err := manager.RemoveSprite("cross"); err != nil {
+		t.Fatal(err)
+	}
+	update(t, gfx, mouse)
+	if mouse.Cursor() != nil {
+		t.Error("Expected no cursor, got", mouse.Cursor())
+	} else if surfaces := gfx.(surface.Resources).Surfaces(); len(surfaces) != 0 {
+		t.Error("Expected no surfaces, got", surfaces)
+	} else if bitmaps := gfx.(surface.Resources).Bitmaps(); len(bitmaps) != 1 {
+		t.Error("Unexpected bitmaps", bitmaps)
+	}
+
+	if _, err := manager.OpenSprites(strings.NewReader("[cross]\nr = red\nR\n")); err != nil {
+		t.Fatal(err)
+	}
+	update(t, gfx, mouse)
+	if sprite := mouse.Cursor(); sprite == nil || sprite.Size() != (gopi.Size{1, 1}) {
+		t.Error("Unexpected cursor", sprite)
+	} else if pixel := read_pixel(t, gfx, 8, 8); pixel != red {
+		t.Error("Expected red at 8,8, got", pixel)
+	} else if pixel := read_pixel(t, gfx, 9, 8); pixel == red {
+		t.Error("Expected transparent at 9,8")
+	}
+}
+
+////////////////////////////////////////////////////////////////////////////////
+// PRIVATE METHODS
+
+func open_cursor(t *testing.T) (gopi.SurfaceManager, sprites.SpriteRegistry, cursor.MouseCursor) {
+	t.Helper()
+	if d, err := gopi.Open(display.VirtualDisplay{Width: 16, Height: 16}, logger.New(t)); err != nil {
+		t.Fatal(err)
+	} else if gfx, err := gopi.Open(surface.SurfaceManager{Display: d.(gopi.Display)}, logger.New(t)); err != nil {
+		t.Fatal(err)
+	} else if manager, err := gopi.Open(sprites.SpriteManager{Graphics: gfx.(gopi.SurfaceManager)}, logger.New(t)); err != nil {
+		t.Fatal(err)
+	} else if _, err := manager.(gopi.SpriteManager).OpenSprites(strings.NewReader(cross)); err != nil {
+		t.Fatal(err)
+	} else if mouse, err := gopi.Open(cursor.Cursor{Graphics: gfx.(gopi.SurfaceManager), Sprites: manager.(gopi.SpriteManager)}, logger.New(t)); err != nil {
+		t.Fatal(err)
+	} else {
+		return gfx.(gopi.SurfaceManager), manager.(sprites.SpriteRegistry), mouse.(cursor.MouseCursor)
+	}
+	return nil, nil, nil
+}
+
+func update(t *testing.T, gfx gopi.SurfaceManager, mouse cursor.MouseCursor) {
+	t.Helper()
+	if err := gfx.Do(mouse.Update); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// read_pixel returns a pixel of the composited display
+func read_pixel(t *testing.T, gfx gopi.SurfaceManager, x, y int) color.NRGBA {
+	t.Helper()
+	if bitmap, err := gfx.CreateSnapshot(gopi.SURFACE_FLAG_RGB888); err != nil {
+		t.Fatal(err)
+	} else {
+		defer gfx.DestroyBitmap(bitmap)
+		if img, err := bitmap.(surface.PixelBitmap).ReadPixels(gopi.ZeroPoint, bitmap.Size()); err != nil {
+			t.Fatal(err)
+		} else {
+			return img.NRGBAAt(x, y)
+		}
+	}
+	return color.NRGBA{}
+}
+
+// expect_cross checks the cross is drawn with the middle at x,y
+func expect_cross(t *testing.T, gfx gopi.SurfaceManager, x, y int) {
+	t.Helper()
+	for _, pt := range [][2]int{{0, 0}, {-1, 0}, {1, 0}, {0, -1}, {0, 1}} {
+		if pixel := read_pixel(t, gfx, x+pt[0], y+pt[1]); pixel != red {
+			t.Errorf("Expected red at %v,%v, got %v", x+pt[0], y+pt[1], pixel)
+		}
+	}
+	for _, pt := range [][2]int{{-1, -1}, {1, -1}, {-1, 1}, {1, 1}} {
+		if pixel := read_pixel(t, gfx, x+pt[0], y+pt[1]); pixel == red {
+			t.Errorf("Expected transparent at %v,%v", x+pt[0], y+pt[1])
+		}
+	}
+}
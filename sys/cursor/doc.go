@@ -0,0 +1,13 @@
+/*
+  Go Language Raspberry Pi Interface
+  (c) Copyright David Thorpe 2019
+  All Rights Reserved
+
+  Documentation http://djthorpe.github.io/gopi/
+  For Licensing and Usage information, please see LICENSE.md
+*/
+
+package cursor
+
+////////////////////////////////////////////////////////////////////////////////
+// EMPTY DOC FILE
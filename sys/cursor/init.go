@@ -0,0 +1,35 @@
+/*
+  Go Language Raspberry Pi Interface
+  (c) Copyright David Thorpe 2019
+  All Rights Reserved
+
+  Documentation http://djthorpe.github.io/gopi/
+  For Licensing and Usage information, please see LICENSE.md
+*/
+
+package cursor
+
+import (
+	"github.com/djthorpe/gopi"
+)
+
+////////////////////////////////////////////////////////////////////////////////
+// INIT
+
+func init() {
+	// Register mouse cursor
+	gopi.RegisterModule(gopi.Module{
+		Name:     "graphics/cursor",
+		Type:     gopi.MODULE_TYPE_OTHER,
+		Requires: []string{"graphics", "graphics/sprites"},
+		New: func(app *gopi.AppInstance) (gopi.Driver, error) {
+			config := Cursor{
+				Graphics: app.Graphics,
+			}
+			if sprites, ok := app.ModuleInstance("graphics/sprites").(gopi.SpriteManager); ok {
+				config.Sprites = sprites
+			}
+			return gopi.Open(config, app.Logger)
+		},
+	})
+}
@@ -27,8 +27,14 @@ func init() {
 		Type:     gopi.MODULE_TYPE_DISPLAY,
 		Config: func(config *gopi.AppConfig) {
 			config.AppFlags.FlagUint("display", 0, "Display")
+			config.AppFlags.FlagString("display.virtual", "", "Virtual display size, <width>x<height>[@<ppi>]")
+			config.AppFlags.FlagString("display.name", "", "Virtual display name")
 		},
 		New: func(app *gopi.AppInstance) (gopi.Driver, error) {
+			// Open a virtual display when the size is set
+			if value, exists := app.AppFlags.GetString("display.virtual"); exists && value != "" {
+				return open_virtual_display(app, value)
+			}
 			display := Display{}
 			if display_number, exists := app.AppFlags.GetUint("display"); exists {
 				display.Display = display_number
@@ -0,0 +1,37 @@
+// +build !rpi
+
+/*
+  Go Language Raspberry Pi Interface
+  (c) Copyright David Thorpe 2016-2019
+  All Rights Reserved
+
+  Documentation http://djthorpe.github.io/gopi/
+  For Licensing and Usage information, please see LICENSE.md
+*/
+
+package display
+
+import (
+	// Frameworks
+	"github.com/djthorpe/gopi"
+)
+
+////////////////////////////////////////////////////////////////////////////////
+// INIT
+
+func init() {
+	// Register Display
+	gopi.RegisterModule(gopi.Module{
+		Name: "graphics/display",
+		Type: gopi.MODULE_TYPE_DISPLAY,
+		Config: func(config *gopi.AppConfig) {
+			config.AppFlags.FlagUint("display", 0, "Display")
+			config.AppFlags.FlagString("display.virtual", "800x480", "Virtual display size, <width>x<height>[@<ppi>]")
+			config.AppFlags.FlagString("display.name", "", "Virtual display name")
+		},
+		New: func(app *gopi.AppInstance) (gopi.Driver, error) {
+			value, _ := app.AppFlags.GetString("display.virtual")
+			return open_virtual_display(app, value)
+		},
+	})
+}
@@ -0,0 +1,155 @@
+/*
+  Go Language Raspberry Pi Interface
+  (c) Copyright David Thorpe 2016-2019
+  All Rights Reserved
+
+  Documentation http://djthorpe.github.io/gopi/
+  For Licensing and Usage information, please see LICENSE.md
+*/
+
+package display
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	// Frameworks
+	gopi "github.com/djthorpe/gopi"
+)
+
+////////////////////////////////////////////////////////////////////////////////
+// TYPES
+
+// VirtualDisplay is a headless display which has no hardware
+// behind it, for use with the software surface manager
+type VirtualDisplay struct {
+	Display       uint
+	Name          string
+	Width, Height uint32
+	PixelsPerInch uint32
+}
+
+type virtual struct {
+	log           gopi.Logger
+	display       uint
+	name          string
+	width, height uint32
+	ppi           uint32
+}
+
+////////////////////////////////////////////////////////////////////////////////
+// CONSTANTS
+
+const (
+	VIRTUAL_DISPLAY_NAME = "virtual"
+)
+
+////////////////////////////////////////////////////////////////////////////////
+// OPEN AND CLOSE
+
+// Open
+func (config VirtualDisplay) Open(logger gopi.Logger) (gopi.Driver, error) {
+	logger.Debug("graphics.display.Open{ display=%v name=%v size={ %v,%v } ppi=%v }", config.Display, config.Name, config.Width, config.Height, config.PixelsPerInch)
+
+	// Check parameters
+	if config.Width == 0 || config.Height == 0 {
+		return nil, gopi.ErrBadParameter
+	}
+
+	this := new(virtual)
+	this.log = logger
+	this.display = config.Display
+	this.width = config.Width
+	this.height = config.Height
+	this.ppi = config.PixelsPerInch
+	if this.name = config.Name; this.name == "" {
+		this.name = VIRTUAL_DISPLAY_NAME
+	}
+
+	// Success
+	return this, nil
+}
+
+// Close
+func (this *virtual) Close() error {
+	this.log.Debug("graphics.display.Close{ display=%v name=%v }", this.display, this.name)
+
+	// Return success
+	return nil
+}
+
+////////////////////////////////////////////////////////////////////////////////
+// PUBLIC METHODS
+
+// Display returns display number
+func (this *virtual) Display() uint {
+	return this.display
+}
+
+// Return size
+func (this *virtual) Size() (uint32, uint32) {
+	return this.width, this.height
+}
+
+// Return pixels-per-inch
+func (this *virtual) PixelsPerInch() uint32 {
+	return this.ppi
+}
+
+// Return name of display
+func (this *virtual) Name() string {
+	return this.name
+}
+
+////////////////////////////////////////////////////////////////////////////////
+// STRINGIFY
+
+func (this *virtual) String() string {
+	return fmt.Sprintf("graphics.display{ id=%v (%v) size={ %v,%v } ppi=%v }", this.name, this.display, this.width, this.height, this.ppi)
+}
+
+////////////////////////////////////////////////////////////////////////////////
+// PRIVATE METHODS
+
+// virtual_display_from_flag returns a virtual display configuration from a
+// flag value of the form <width>x<height>[@<ppi>]
+func virtual_display_from_flag(value string) (VirtualDisplay, error) {
+	config := VirtualDisplay{}
+	if value = strings.TrimSpace(value); value == "" {
+		return config, gopi.ErrBadParameter
+	}
+	if parts := strings.SplitN(value, "@", 2); len(parts) == 2 {
+		if ppi, err := strconv.ParseUint(parts[1], 10, 32); err != nil {
+			return config, fmt.Errorf("Invalid pixels per inch: %v", value)
+		} else {
+			config.PixelsPerInch = uint32(ppi)
+			value = parts[0]
+		}
+	}
+	if parts := strings.SplitN(strings.ToLower(value), "x", 2); len(parts) != 2 {
+		return config, fmt.Errorf("Invalid display size: %v", value)
+	} else if w, err := strconv.ParseUint(parts[0], 10, 32); err != nil || w == 0 {
+		return config, fmt.Errorf("Invalid display width: %v", value)
+	} else if h, err := strconv.ParseUint(parts[1], 10, 32); err != nil || h == 0 {
+		return config, fmt.Errorf("Invalid display height: %v", value)
+	} else {
+		config.Width = uint32(w)
+		config.Height = uint32(h)
+	}
+	return config, nil
+}
+
+func open_virtual_display(app *gopi.AppInstance, value string) (gopi.Driver, error) {
+	if display, err := virtual_display_from_flag(value); err != nil {
+		return nil, err
+	} else {
+		if display_number, exists := app.AppFlags.GetUint("display"); exists {
+			display.Display = display_number
+		}
+		if name, exists := app.AppFlags.GetString("display.name"); exists {
+			display.Name = name
+		}
+		return gopi.Open(display, app.Logger)
+	}
+}
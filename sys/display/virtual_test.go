@@ -0,0 +1,79 @@
+/*
+  Go Language Raspberry Pi Interface
+  (c) Copyright David Thorpe 2016-2019
+  All Rights Reserved
+
+  Documentation http://djthorpe.github.io/gopi/
+  For Licensing and Usage information, please see LICENSE.md
+*/
+
+package display
+
+import (
+	"testing"
+
+	// Frameworks
+	gopi "github.com/djthorpe/gopi"
+	logger "github.com/djthorpe/gopi-graphics/sys/internal/logger"
+)
+
+////////////////////////////////////////////////////////////////////////////////
+// TESTS
+
+func Test_Flag_000(t *testing.T) {
+	// Parse valid flag values
+	tests := []struct {
+		value  string
+		config VirtualDisplay
+	}{
+		{"800x480", VirtualDisplay{Width: 800, Height: 480}},
+		{"1920x1080@96", VirtualDisplay{Width: 1920, Height: 1080, PixelsPerInch: 96}},
+		{" 640X480 ", VirtualDisplay{Width: 640, Height: 480}},
+	}
+	for _, test := range tests {
+		if config, err := virtual_display_from_flag(test.value); err != nil {
+			t.Errorf("%q: %v", test.value, err)
+		} else if config != test.config {
+			t.Errorf("%q: expected %+v, got %+v", test.value, test.config, config)
+		}
+	}
+}
+
+func Test_Flag_001(t *testing.T) {
+	// Reject malformed flag values
+	for _, value := range []string{
+		"", "x", "800", "0x0", "0x480", "800x0", "10x10@", "@96",
+		"-1x10", "10x-1", "10x10@-1", "axb", "10x10x10",
+		"4294967296x10", "10x4294967296", "10x10@4294967296",
+	} {
+		if config, err := virtual_display_from_flag(value); err == nil {
+			t.Errorf("%q: expected error, got %+v", value, config)
+		}
+	}
+}
+
+func Test_Virtual_000(t *testing.T) {
+	// Open and close a virtual display
+	driver, err := gopi.Open(VirtualDisplay{Display: 1, Width: 800, Height: 480, PixelsPerInch: 96}, logger.New(t))
+	if err != nil {
+		t.Fatal(err)
+	}
+	display := driver.(gopi.Display)
+	if w, h := display.Size(); w != 800 || h != 480 {
+		t.Error("Unexpected size", w, h)
+	} else if ppi := display.PixelsPerInch(); ppi != 96 {
+		t.Error("Unexpected pixels per inch", ppi)
+	} else if number := display.Display(); number != 1 {
+		t.Error("Unexpected display number", number)
+	} else if name := display.Name(); name != VIRTUAL_DISPLAY_NAME {
+		t.Error("Unexpected name", name)
+	}
+	if err := driver.Close(); err != nil {
+		t.Error(err)
+	}
+
+	// A display must have a size
+	if _, err := gopi.Open(VirtualDisplay{Width: 800}, logger.New(t)); err != gopi.ErrBadParameter {
+		t.Error("Expected ErrBadParameter, got", err)
+	}
+}
@@ -0,0 +1,155 @@
+/*
+  Go Language Raspberry Pi Interface
+  (c) Copyright David Thorpe 2016-2019
+  All Rights Reserved
+
+  Documentation http://djthorpe.github.io/gopi/
+  For Licensing and Usage information, please see LICENSE.md
+*/
+
+package fonts
+
+import (
+	"container/list"
+	"fmt"
+	"sync"
+)
+
+////////////////////////////////////////////////////////////////////////////////
+// TYPES
+
+// GlyphCache is implemented by the font manager to report on the
+// cache of rendered glyphs
+type GlyphCache interface {
+	// Return statistics for the glyph cache
+	GlyphCacheStats() GlyphCacheStats
+}
+
+// GlyphCacheStats are the number of cache hits and misses, the number
+// of glyphs in the cache and the size of the cache in bytes
+type GlyphCacheStats struct {
+	Hits, Misses uint64
+	Glyphs       uint
+	Size, Budget uint
+}
+
+type glyph_key struct {
+	face   *face
+	points float32
+	ppi    uint
+	value  rune
+}
+
+type glyph_entry struct {
+	key   glyph_key
+	glyph *Glyph
+	size  uint
+}
+
+// glyph_cache retains rendered glyphs up to a budget in bytes,
+// discarding the least recently used glyphs
+type glyph_cache struct {
+	budget, size uint
+	hits, misses uint64
+	entries      map[glyph_key]*list.Element
+	lru          *list.List
+	sync.Mutex
+}
+
+////////////////////////////////////////////////////////////////////////////////
+// CONSTANTS
+
+const (
+	// Approximate size of a glyph in the cache excluding the coverage
+	GLYPH_CACHE_ENTRY_SIZE = 96
+)
+
+////////////////////////////////////////////////////////////////////////////////
+// NEW
+
+func new_glyph_cache(budget uint) *glyph_cache {
+	return &glyph_cache{
+		budget:  budget,
+		entries: make(map[glyph_key]*list.Element),
+		lru:     list.New(),
+	}
+}
+
+////////////////////////////////////////////////////////////////////////////////
+// IMPLEMENTATION
+
+// Get returns a glyph from the cache or nil
+func (this *glyph_cache) Get(key glyph_key) *Glyph {
+	this.Lock()
+	defer this.Unlock()
+
+	if element, exists := this.entries[key]; exists {
+		this.hits++
+		this.lru.MoveToFront(element)
+		return element.Value.(*glyph_entry).glyph
+	} else {
+		this.misses++
+		return nil
+	}
+}
+
+// Put adds a glyph to the cache and discards the least recently
+// used glyphs when the cache is over budget
+func (this *glyph_cache) Put(key glyph_key, glyph *Glyph) {
+	this.Lock()
+	defer this.Unlock()
+
+	if element, exists := this.entries[key]; exists {
+		this.remove(element)
+	}
+	entry := &glyph_entry{key, glyph, uint(len(glyph.Coverage)) + GLYPH_CACHE_ENTRY_SIZE}
+	if entry.size > this.budget {
+		return
+	}
+	this.entries[key] = this.lru.PushFront(entry)
+	this.size += entry.size
+	for this.size > this.budget {
+		this.remove(this.lru.Back())
+	}
+}
+
+// Invalidate removes all glyphs for a face from the cache
+func (this *glyph_cache) Invalidate(face *face) {
+	this.Lock()
+	defer this.Unlock()
+
+	for key, element := range this.entries {
+		if key.face == face {
+			this.remove(element)
+		}
+	}
+}
+
+func (this *glyph_cache) Stats() GlyphCacheStats {
+	this.Lock()
+	defer this.Unlock()
+
+	return GlyphCacheStats{
+		Hits:   this.hits,
+		Misses: this.misses,
+		Glyphs: uint(len(this.entries)),
+		Size:   this.size,
+		Budget: this.budget,
+	}
+}
+
+////////////////////////////////////////////////////////////////////////////////
+// STRINGIFY
+
+func (this GlyphCacheStats) String() string {
+	return fmt.Sprintf("<graphics.fonts.GlyphCacheStats>{ hits=%v misses=%v glyphs=%v size=%v budget=%v }", this.Hits, this.Misses, this.Glyphs, this.Size, this.Budget)
+}
+
+////////////////////////////////////////////////////////////////////////////////
+// PRIVATE METHODS
+
+func (this *glyph_cache) remove(element *list.Element) {
+	entry := this.lru.Remove(element).(*glyph_entry)
+	delete(this.entries, entry.key)
+	this.size -= entry.size
+}
@@ -0,0 +1,78 @@
+/*
+  Go Language Raspberry Pi Interface
+  (c) Copyright David Thorpe 2016-2019
+  All Rights Reserved
+
+  Documentation http://djthorpe.github.io/gopi/
+  For Licensing and Usage information, please see LICENSE.md
+*/
+
+package fonts
+
+import (
+	"testing"
+)
+
+////////////////////////////////////////////////////////////////////////////////
+// TESTS
+
+func Test_Cache_000(t *testing.T) {
+	// Discard the least recently used glyph when over budget
+	cache := new_glyph_cache(3 * (GLYPH_CACHE_ENTRY_SIZE + 4))
+	for _, value := range "abc" {
+		cache.Put(key_for_rune(nil, value), glyph_for_rune(value, 4))
+	}
+	if cache.Get(key_for_rune(nil, 'a')) == nil {
+		t.Error("Expected glyph for 'a'")
+	}
+	cache.Put(key_for_rune(nil, 'd'), glyph_for_rune('d', 4))
+	if cache.Get(key_for_rune(nil, 'b')) != nil {
+		t.Error("Expected glyph for 'b' to be discarded")
+	}
+	for _, value := range "acd" {
+		if glyph := cache.Get(key_for_rune(nil, value)); glyph == nil || glyph.Rune != value {
+			t.Errorf("Expected glyph for '%c', got %v", value, glyph)
+		}
+	}
+
+	// Hits are 'a' and then 'a', 'c' and 'd', and the miss is 'b'
+	if stats := cache.Stats(); stats.Hits != 4 || stats.Misses != 1 {
+		t.Error("Unexpected hits and misses", stats)
+	} else if stats.Glyphs != 3 || stats.Size != 3*(GLYPH_CACHE_ENTRY_SIZE+4) {
+		t.Error("Unexpected size", stats)
+	}
+}
+
+func Test_Cache_001(t *testing.T) {
+	// Replace glyphs, skip glyphs over budget and invalidate faces
+	face1, face2 := new(face), new(face)
+	cache := new_glyph_cache(4 * (GLYPH_CACHE_ENTRY_SIZE + 4))
+	cache.Put(key_for_rune(face1, 'a'), glyph_for_rune('a', 4))
+	cache.Put(key_for_rune(face1, 'a'), glyph_for_rune('a', 4))
+	cache.Put(key_for_rune(face1, 'b'), glyph_for_rune('b', 4))
+	cache.Put(key_for_rune(face2, 'a'), glyph_for_rune('a', 4))
+	cache.Put(key_for_rune(face2, 'z'), glyph_for_rune('z', 4*(GLYPH_CACHE_ENTRY_SIZE+4)))
+	if stats := cache.Stats(); stats.Glyphs != 3 || stats.Size != 3*(GLYPH_CACHE_ENTRY_SIZE+4) {
+		t.Error("Unexpected size", stats)
+	}
+
+	cache.Invalidate(face1)
+	if stats := cache.Stats(); stats.Glyphs != 1 || stats.Size != GLYPH_CACHE_ENTRY_SIZE+4 {
+		t.Error("Unexpected size", stats)
+	} else if cache.Get(key_for_rune(face1, 'a')) != nil {
+		t.Error("Expected glyph to be invalidated")
+	} else if cache.Get(key_for_rune(face2, 'a')) == nil {
+		t.Error("Expected glyph for another face")
+	}
+}
+
+////////////////////////////////////////////////////////////////////////////////
+// PRIVATE METHODS
+
+func key_for_rune(face *face, value rune) glyph_key {
+	return glyph_key{face, 12, 72, value}
+}
+
+func glyph_for_rune(value rune, size uint) *Glyph {
+	return &Glyph{Rune: value, Width: size, Height: 1, Pitch: size, Coverage: make([]byte, size)}
+}
@@ -11,7 +11,10 @@ package fonts
 
 import (
 	"fmt"
+	"image"
+	"math"
 	"path"
+	"strings"
 
 	// Frameworks
 	"github.com/djthorpe/gopi"
@@ -52,3 +55,191 @@ func (this *face) NumGlyphs() uint {
 func (this *face) Flags() gopi.FontFlags {
 	return ft.FT_FaceStyleFlags(this.handle)
 }
+
+////////////////////////////////////////////////////////////////////////////////
+// PUBLIC FUNCTIONS: Glyphs
+
+func (this *face) SetSize(points float32, ppi uint) error {
+	this.Lock()
+	defer this.Unlock()
+	return this.set_size(points, ppi)
+}
+
+func (this *face) Size() (float32, uint) {
+	this.Lock()
+	defer this.Unlock()
+	return this.points, this.ppi
+}
+
+// GlyphForRune returns the glyph for a rune at the current size,
+// or ErrBadParameter if the face has no glyph for the rune. The
+// glyph may be shared through the glyph cache so should not be
+// modified
+func (this *face) GlyphForRune(value rune) (*Glyph, error) {
+	this.Lock()
+	defer this.Unlock()
+
+	if this.points == 0 {
+		return nil, gopi.ErrOutOfOrder
+	} else {
+		return this.glyph(value)
+	}
+}
+
+// Metrics returns the metrics for the face at the current size
+func (this *face) Metrics() (FaceMetrics, error) {
+	this.Lock()
+	defer this.Unlock()
+
+	if this.points == 0 {
+		return FaceMetrics{}, gopi.ErrOutOfOrder
+	} else {
+		return this.metrics(), nil
+	}
+}
+
+////////////////////////////////////////////////////////////////////////////////
+// PRIVATE METHODS
+
+type placement struct {
+	glyph *Glyph
+	x, y  float32
+}
+
+// set_size sets the size of the face when it has changed
+func (this *face) set_size(points float32, ppi uint) error {
+	if points <= 0 {
+		return gopi.ErrBadParameter
+	} else if points == this.points && ppi == this.ppi {
+		return nil
+	} else if err := ft_set_size(this.handle, points, ppi); err != nil {
+		return err
+	} else {
+		this.points = points
+		this.ppi = ppi
+		return nil
+	}
+}
+
+// glyph returns the glyph for a rune at the current size from the
+// cache, or renders the glyph and adds it to the cache
+func (this *face) glyph(value rune) (*Glyph, error) {
+	key := glyph_key{this, this.points, this.ppi, value}
+	if this.cache != nil {
+		if glyph := this.cache.Get(key); glyph != nil {
+			return glyph, nil
+		}
+	}
+	if glyph, err := ft_load_glyph(this.handle, value); err != nil {
+		return nil, err
+	} else {
+		if this.cache != nil {
+			this.cache.Put(key, glyph)
+		}
+		return glyph, nil
+	}
+}
+
+// place returns the pen position of each glyph on a line of text,
+// kerning between pairs of glyphs, and the advance of the line. Runes
+// which are not in the face are skipped
+func (this *face) place(line string, baseline float32) ([]placement, float32, error) {
+	glyphs := make([]placement, 0, len(line))
+	pen, previous := float32(0), uint(0)
+	for _, value := range line {
+		if glyph, err := this.glyph(value); err == gopi.ErrBadParameter {
+			continue
+		} else if err != nil {
+			return nil, 0, err
+		} else {
+			pen += ft_kerning(this.handle, previous, glyph.Index)
+			glyphs = append(glyphs, placement{glyph, pen, baseline})
+			pen += glyph.Advance.X
+			previous = glyph.Index
+		}
+	}
+	return glyphs, pen, nil
+}
+
+// advance returns the advance of a line of text
+func (this *face) advance(line string) (float32, error) {
+	_, advance, err := this.place(line, 0)
+	return advance, err
+}
+
+// offset returns the position of the start of a line and the first
+// baseline relative to the origin of the text for the alignment
+func (this *face) offset(align TextAlign, advance float32, lines uint) gopi.Point {
+	offset := gopi.ZeroPoint
+	switch align & TEXT_ALIGN_HMASK {
+	case TEXT_ALIGN_CENTER:
+		offset.X = -advance / 2
+	case TEXT_ALIGN_RIGHT:
+		offset.X = -advance
+	}
+	ascent, descent, height := ft_size_metrics(this.handle)
+	switch align & TEXT_ALIGN_VMASK {
+	case TEXT_ALIGN_TOP:
+		offset.Y = ascent
+	case TEXT_ALIGN_MIDDLE:
+		offset.Y = ascent - (ascent-descent+float32(lines-1)*height)/2
+	case TEXT_ALIGN_BOTTOM:
+		offset.Y = descent - float32(lines-1)*height
+	}
+	return offset
+}
+
+// mask sets the size of the face and renders lines of text into an alpha
+// mask, where the point (0,0) in the mask is the origin of the text for
+// the alignment
+func (this *face) mask(points float32, ppi uint, align TextAlign, text string) (*image.Alpha, error) {
+	this.Lock()
+	defer this.Unlock()
+
+	if err := this.set_size(points, ppi); err != nil {
+		return nil, err
+	}
+
+	// Place the glyphs on each line and align them
+	_, _, height := ft_size_metrics(this.handle)
+	lines := strings.Split(text, "\n")
+	glyphs := make([]placement, 0, len(text))
+	for i, line := range lines {
+		if placements, advance, err := this.place(line, float32(i)*height); err != nil {
+			return nil, err
+		} else {
+			offset := this.offset(align, advance, uint(len(lines)))
+			for _, placement := range placements {
+				placement.x += offset.X
+				placement.y += offset.Y
+				glyphs = append(glyphs, placement)
+			}
+		}
+	}
+
+	// Determine the bounds of the glyphs
+	bounds := image.ZR
+	for i := range glyphs {
+		glyph := glyphs[i].glyph
+		glyphs[i].x = float32(math.Floor(float64(glyphs[i].x + glyph.Bearing.X + 0.5)))
+		glyphs[i].y = float32(math.Floor(float64(glyphs[i].y - glyph.Bearing.Y + 0.5)))
+		bounds = bounds.Union(image.Rect(int(glyphs[i].x), int(glyphs[i].y), int(glyphs[i].x)+int(glyph.Width), int(glyphs[i].y)+int(glyph.Height)))
+	}
+
+	// Draw the coverage of each glyph into the mask
+	mask := image.NewAlpha(bounds)
+	for _, placement := range glyphs {
+		glyph := placement.glyph
+		for y := uint(0); y < glyph.Height; y++ {
+			i := mask.PixOffset(int(placement.x), int(placement.y)+int(y))
+			for x, coverage := range glyph.Coverage[y*glyph.Pitch : y*glyph.Pitch+glyph.Width] {
+				if coverage > mask.Pix[i+x] {
+					mask.Pix[i+x] = coverage
+				}
+			}
+		}
+	}
+
+	// Return the mask
+	return mask, nil
+}
@@ -0,0 +1,137 @@
+/*
+  Go Language Raspberry Pi Interface
+  (c) Copyright David Thorpe 2016-2019
+  All Rights Reserved
+
+  Documentation http://djthorpe.github.io/gopi/
+  For Licensing and Usage information, please see LICENSE.md
+*/
+
+package fonts_test
+
+import (
+	"testing"
+
+	// Frameworks
+	gopi "github.com/djthorpe/gopi"
+	fonts "github.com/djthorpe/gopi-graphics/sys/fonts"
+	logger "github.com/djthorpe/gopi-graphics/sys/internal/logger"
+)
+
+////////////////////////////////////////////////////////////////////////////////
+// GLOBAL VARIABLES
+
+const (
+	FACE_PATH = "../../etc/fonts/Roboto/Roboto-Regular.ttf"
+)
+
+////////////////////////////////////////////////////////////////////////////////
+// TESTS
+
+func Test_Glyph_000(t *testing.T) {
+	// Render a glyph, where the size must be set first
+	manager, face := open_face(t)
+	defer manager.Close()
+	if _, err := face.GlyphForRune('H'); err != gopi.ErrOutOfOrder {
+		t.Error("Expected ErrOutOfOrder, got", err)
+	} else if err := face.SetSize(20, 0); err != nil {
+		t.Fatal(err)
+	} else if points, ppi := face.Size(); points != 20 || ppi != 0 {
+		t.Error("Unexpected size", points, ppi)
+	}
+
+	glyph, err := face.GlyphForRune('H')
+	if err != nil {
+		t.Fatal(err)
+	} else if glyph.Rune != 'H' || glyph.Index == 0 {
+		t.Error("Unexpected glyph", glyph.Rune, glyph.Index)
+	} else if glyph.Width == 0 || glyph.Height == 0 || glyph.Height > 20 {
+		t.Error("Unexpected glyph size", glyph.Width, glyph.Height)
+	} else if glyph.Bearing.Y != float32(glyph.Height) {
+		t.Error("Expected glyph to sit on the baseline", glyph.Bearing)
+	} else if glyph.Advance.X < float32(glyph.Width) || glyph.Advance.Y != 0 {
+		t.Error("Unexpected advance", glyph.Advance)
+	} else if uint(len(glyph.Coverage)) != glyph.Pitch*glyph.Height {
+		t.Error("Unexpected coverage", len(glyph.Coverage))
+	}
+
+	// The stems of the H are fully covered down the whole glyph
+	for y := uint(0); y < glyph.Height; y++ {
+		row := glyph.Coverage[y*glyph.Pitch : y*glyph.Pitch+glyph.Width]
+		if max_coverage(row[:glyph.Width/2]) != 0xFF || max_coverage(row[glyph.Width/2:]) != 0xFF {
+			t.Errorf("Expected stems in row %v: %v", y, row)
+		}
+	}
+
+	// A larger size renders a larger glyph
+	if err := face.SetSize(40, 0); err != nil {
+		t.Fatal(err)
+	} else if glyph_, err := face.GlyphForRune('H'); err != nil {
+		t.Fatal(err)
+	} else if glyph_.Height <= glyph.Height {
+		t.Error("Expected larger glyph", glyph_.Height)
+	}
+
+	// Runes which are not in the face return an error
+	if _, err := face.GlyphForRune(0xE000); err != gopi.ErrBadParameter {
+		t.Error("Expected ErrBadParameter, got", err)
+	}
+}
+
+func Test_Glyph_001(t *testing.T) {
+	// Glyphs are rendered once for each size and then cached
+	manager, face := open_face(t)
+	defer manager.Close()
+	if err := face.SetSize(20, 0); err != nil {
+		t.Fatal(err)
+	}
+	for _, value := range "HelloH" {
+		if _, err := face.GlyphForRune(value); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if stats := manager.(fonts.GlyphCache).GlyphCacheStats(); stats.Hits != 2 || stats.Misses != 4 || stats.Glyphs != 4 {
+		t.Error("Unexpected stats", stats)
+	} else if stats.Size == 0 || stats.Size > stats.Budget {
+		t.Error("Unexpected size", stats)
+	}
+
+	// A different size is a miss, and destroying the face empties the cache
+	if err := face.SetSize(24, 0); err != nil {
+		t.Fatal(err)
+	} else if _, err := face.GlyphForRune('H'); err != nil {
+		t.Fatal(err)
+	} else if stats := manager.(fonts.GlyphCache).GlyphCacheStats(); stats.Misses != 5 || stats.Glyphs != 5 {
+		t.Error("Unexpected stats", stats)
+	} else if err := manager.DestroyFace(face); err != nil {
+		t.Fatal(err)
+	} else if stats := manager.(fonts.GlyphCache).GlyphCacheStats(); stats.Glyphs != 0 || stats.Size != 0 {
+		t.Error("Unexpected stats", stats)
+	}
+}
+
+////////////////////////////////////////////////////////////////////////////////
+// PRIVATE METHODS
+
+func open_face(t *testing.T) (gopi.FontManager, fonts.Face) {
+	t.Helper()
+	if manager, err := gopi.Open(fonts.FontManager{}, logger.New(t)); err != nil {
+		t.Fatal(err)
+	} else if face, err := manager.(gopi.FontManager).OpenFace(FACE_PATH); err != nil {
+		manager.Close()
+		t.Fatal(err)
+	} else {
+		return manager.(gopi.FontManager), face.(fonts.Face)
+	}
+	return nil, nil
+}
+
+func max_coverage(row []byte) byte {
+	max := byte(0)
+	for _, coverage := range row {
+		if coverage > max {
+			max = coverage
+		}
+	}
+	return max
+}
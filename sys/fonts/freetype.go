@@ -0,0 +1,145 @@
+/*
+  Go Language Raspberry Pi Interface
+  (c) Copyright David Thorpe 2016-2019
+  All Rights Reserved
+
+  Documentation http://djthorpe.github.io/gopi/
+  For Licensing and Usage information, please see LICENSE.md
+*/
+
+package fonts
+
+import (
+	"fmt"
+	"unsafe"
+
+	// Frameworks
+	"github.com/djthorpe/gopi"
+	ft "github.com/djthorpe/gopi-hw/freetype"
+)
+
+////////////////////////////////////////////////////////////////////////////////
+// CGO
+
+/*
+  #cgo pkg-config: freetype2
+  #include <ft2build.h>
+  #include FT_FREETYPE_H
+*/
+import "C"
+
+////////////////////////////////////////////////////////////////////////////////
+// CONSTANTS
+
+const (
+	FT_LOAD_RENDER      = (1 << 2)
+	FT_PIXEL_MODE_MONO  = 1 // 1 bit per pixel
+	FT_PIXEL_MODE_GRAY  = 2 // 8 bits per pixel
+	FT_FIXED_POINT_26_6 = 64.0
+)
+
+////////////////////////////////////////////////////////////////////////////////
+// PRIVATE METHODS
+
+func ft_face(handle ft.FT_Face) C.FT_Face {
+	return C.FT_Face(unsafe.Pointer(handle))
+}
+
+func ft_error(ret C.FT_Error) error {
+	if ret == 0 {
+		return nil
+	} else {
+		return fmt.Errorf("FreeType error 0x%02X", int(ret))
+	}
+}
+
+// ft_set_size sets the face size in points at a resolution, or
+// in pixels when the resolution is zero
+func ft_set_size(handle ft.FT_Face, points float32, ppi uint) error {
+	if ppi == 0 {
+		return ft_error(C.FT_Set_Pixel_Sizes(ft_face(handle), 0, C.FT_UInt(points)))
+	} else {
+		return ft_error(C.FT_Set_Char_Size(ft_face(handle), 0, C.FT_F26Dot6(points*FT_FIXED_POINT_26_6), 0, C.FT_UInt(ppi)))
+	}
+}
+
+// ft_load_glyph renders the glyph for a rune and returns it with
+// eight bits of coverage per pixel
+func ft_load_glyph(handle ft.FT_Face, value rune) (*Glyph, error) {
+	face := ft_face(handle)
+
+	// Get glyph and render
+	index := C.FT_Get_Char_Index(face, C.FT_ULong(value))
+	if index == 0 {
+		return nil, gopi.ErrBadParameter
+	} else if err := ft_error(C.FT_Load_Glyph(face, index, C.FT_Int32(FT_LOAD_RENDER))); err != nil {
+		return nil, err
+	}
+
+	// Copy the bitmap rows from the glyph slot, top row first
+	slot := face.glyph
+	bitmap := slot.bitmap
+	glyph := &Glyph{
+		Rune:    value,
+		Index:   uint(index),
+		Width:   uint(bitmap.width),
+		Height:  uint(bitmap.rows),
+		Pitch:   uint(bitmap.width),
+		Bearing: gopi.Point{float32(slot.bitmap_left), float32(slot.bitmap_top)},
+		Advance: gopi.Point{float32(slot.advance.x) / FT_FIXED_POINT_26_6, float32(slot.advance.y) / FT_FIXED_POINT_26_6},
+	}
+	glyph.Coverage = make([]byte, glyph.Pitch*glyph.Height)
+	if glyph.Width == 0 || glyph.Height == 0 {
+		return glyph, nil
+	}
+	pitch := int(bitmap.pitch)
+	if pitch < 0 {
+		pitch = -pitch
+	}
+	buffer := C.GoBytes(unsafe.Pointer(bitmap.buffer), C.int(pitch*int(glyph.Height)))
+	for y := uint(0); y < glyph.Height; y++ {
+		src := buffer[int(y)*pitch:]
+		if bitmap.pitch < 0 {
+			src = buffer[int(glyph.Height-y-1)*pitch:]
+		}
+		dst := glyph.Coverage[y*glyph.Pitch : (y+1)*glyph.Pitch]
+		switch bitmap.pixel_mode {
+		case FT_PIXEL_MODE_GRAY:
+			copy(dst, src[:glyph.Width])
+		case FT_PIXEL_MODE_MONO:
+			for x := range dst {
+				if src[x>>3]&(0x80>>uint(x&7)) != 0 {
+					dst[x] = 0xFF
+				}
+			}
+		default:
+			return nil, gopi.ErrNotImplemented
+		}
+	}
+
+	// Success
+	return glyph, nil
+}
+
+// ft_kerning returns the horizontal kerning in pixels between two glyphs,
+// or zero if the face has no kerning information
+func ft_kerning(handle ft.FT_Face, left, right uint) float32 {
+	face := ft_face(handle)
+	if face.face_flags&C.FT_FACE_FLAG_KERNING == 0 || left == 0 || right == 0 {
+		return 0
+	}
+	var kerning C.FT_Vector
+	if err := ft_error(C.FT_Get_Kerning(face, C.FT_UInt(left), C.FT_UInt(right), C.FT_KERNING_DEFAULT, &kerning)); err != nil {
+		return 0
+	} else {
+		return float32(kerning.x) / FT_FIXED_POINT_26_6
+	}
+}
+
+// ft_size_metrics returns the ascent and descent from the baseline in
+// pixels at the current size, where descent is negative, and the
+// distance between baselines
+func ft_size_metrics(handle ft.FT_Face) (float32, float32, float32) {
+	metrics := ft_face(handle).size.metrics
+	return float32(metrics.ascender) / FT_FIXED_POINT_26_6, float32(metrics.descender) / FT_FIXED_POINT_26_6, float32(metrics.height) / FT_FIXED_POINT_26_6
+}
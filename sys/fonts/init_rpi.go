@@ -22,8 +22,18 @@ func init() {
 	gopi.RegisterModule(gopi.Module{
 		Name: "graphics/fonts",
 		Type: gopi.MODULE_TYPE_FONTS,
+		Config: func(config *gopi.AppConfig) {
+			config.AppFlags.FlagUint("font.cache", FONT_GLYPH_CACHE_SIZE_DEFAULT/1024, "Glyph cache size in kilobytes")
+		},
 		New: func(app *gopi.AppInstance) (gopi.Driver, error) {
-			return gopi.Open(FontManager{}, app.Logger)
+			config := FontManager{}
+			if cache, exists := app.AppFlags.GetUint("font.cache"); exists {
+				config.GlyphCacheSize = cache * 1024
+			}
+			if app.Display != nil {
+				config.PixelsPerInch = uint(app.Display.PixelsPerInch())
+			}
+			return gopi.Open(config, app.Logger)
 		},
 	})
 }
@@ -0,0 +1,263 @@
+/*
+  Go Language Raspberry Pi Interface
+  (c) Copyright David Thorpe 2016-2019
+  All Rights Reserved
+
+  Documentation http://djthorpe.github.io/gopi/
+  For Licensing and Usage information, please see LICENSE.md
+*/
+
+package fonts
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+
+	// Frameworks
+	"github.com/djthorpe/gopi"
+)
+
+////////////////////////////////////////////////////////////////////////////////
+// TYPES
+
+// TextLayout is implemented by the font manager to measure and wrap
+// text before it is drawn, using the same sizes as DrawString
+type TextLayout interface {
+	// Return the metrics for a face at a point size
+	FaceMetrics(face gopi.FontFace, size float32) (FaceMetrics, error)
+
+	// Return the top left and size of the box which contains lines of
+	// text, relative to the origin of the text for the alignment
+	MeasureString(face gopi.FontFace, size float32, align TextAlign, text string) (gopi.Point, gopi.Size, error)
+
+	// Break text into lines no wider than a width, where zero width does
+	// not break lines. When there are more lines than the maximum number of
+	// lines the last line is truncated with an ellipsis, where zero lines
+	// does not truncate
+	WrapString(face gopi.FontFace, size float32, width float32, lines uint, text string) ([]string, error)
+}
+
+// FaceMetrics are the distances in pixels above and below the baseline
+// at a size, where descent is negative, the gap between the descent of
+// one line and the ascent of the next, and the distance between baselines
+type FaceMetrics struct {
+	Ascent, Descent float32
+	LineGap         float32
+	Height          float32
+}
+
+////////////////////////////////////////////////////////////////////////////////
+// CONSTANTS
+
+const (
+	TEXT_ELLIPSIS          = "…"
+	TEXT_ELLIPSIS_FALLBACK = "..."
+)
+
+////////////////////////////////////////////////////////////////////////////////
+// LAYOUT
+
+func (this *manager) FaceMetrics(f gopi.FontFace, size float32) (FaceMetrics, error) {
+	this.log.Debug2("<graphics.fonts>FaceMetrics{ face=%v size=%v }", f, size)
+
+	if face_, ok := f.(*face); ok == false {
+		return FaceMetrics{}, gopi.ErrBadParameter
+	} else {
+		face_.Lock()
+		defer face_.Unlock()
+		if err := face_.set_size(size, this.ppi); err != nil {
+			return FaceMetrics{}, err
+		} else {
+			return face_.metrics(), nil
+		}
+	}
+}
+
+func (this *manager) MeasureString(f gopi.FontFace, size float32, align TextAlign, text string) (gopi.Point, gopi.Size, error) {
+	this.log.Debug2("<graphics.fonts>MeasureString{ face=%v size=%v align=%v text=%v }", f, size, align, text)
+
+	if face_, ok := f.(*face); ok == false {
+		return gopi.ZeroPoint, gopi.ZeroSize, gopi.ErrBadParameter
+	} else {
+		face_.Lock()
+		defer face_.Unlock()
+		if err := face_.set_size(size, this.ppi); err != nil {
+			return gopi.ZeroPoint, gopi.ZeroSize, err
+		} else {
+			return face_.measure(align, text)
+		}
+	}
+}
+
+func (this *manager) WrapString(f gopi.FontFace, size float32, width float32, lines uint, text string) ([]string, error) {
+	this.log.Debug2("<graphics.fonts>WrapString{ face=%v size=%v width=%v lines=%v text=%v }", f, size, width, lines, text)
+
+	if width < 0 {
+		return nil, gopi.ErrBadParameter
+	} else if face_, ok := f.(*face); ok == false {
+		return nil, gopi.ErrBadParameter
+	} else {
+		face_.Lock()
+		defer face_.Unlock()
+		if err := face_.set_size(size, this.ppi); err != nil {
+			return nil, err
+		} else {
+			return face_.wrap(width, lines, text)
+		}
+	}
+}
+
+////////////////////////////////////////////////////////////////////////////////
+// STRINGIFY
+
+func (this FaceMetrics) String() string {
+	return fmt.Sprintf("<graphics.fonts.FaceMetrics>{ ascent=%v descent=%v line_gap=%v height=%v }", this.Ascent, this.Descent, this.LineGap, this.Height)
+}
+
+////////////////////////////////////////////////////////////////////////////////
+// PRIVATE METHODS
+
+func (this *face) metrics() FaceMetrics {
+	ascent, descent, height := ft_size_metrics(this.handle)
+	return FaceMetrics{ascent, descent, height - (ascent - descent), height}
+}
+
+// measure returns the box which contains lines of text at the current size
+func (this *face) measure(align TextAlign, text string) (gopi.Point, gopi.Size, error) {
+	lines := strings.Split(text, "\n")
+	width := float32(0)
+	for _, line := range lines {
+		if advance, err := this.advance(line); err != nil {
+			return gopi.ZeroPoint, gopi.ZeroSize, err
+		} else if advance > width {
+			width = advance
+		}
+	}
+	metrics := this.metrics()
+	offset := this.offset(align, width, uint(len(lines)))
+	origin := gopi.Point{offset.X, offset.Y - metrics.Ascent}
+	size := gopi.Size{width, metrics.Ascent - metrics.Descent + float32(len(lines)-1)*metrics.Height}
+	return origin, size, nil
+}
+
+// wrap breaks text into lines at the current size
+func (this *face) wrap(width float32, max uint, text string) ([]string, error) {
+	lines := make([]string, 0)
+	for _, paragraph := range strings.Split(text, "\n") {
+		if width == 0 {
+			lines = append(lines, paragraph)
+			continue
+		}
+		line, start := "", len(lines)
+		for _, segment := range segments(paragraph) {
+			if fits, err := this.fits(line+segment, width); err != nil {
+				return nil, err
+			} else if fits {
+				line = line + segment
+				continue
+			} else if line != "" {
+				lines = append(lines, trim_line(line))
+			}
+			// Break a segment which is wider than the line between runes
+			line = segment
+			for {
+				if fits, err := this.fits(line, width); err != nil {
+					return nil, err
+				} else if fits {
+					break
+				} else if prefix, err := this.prefix(line, width, ""); err != nil {
+					return nil, err
+				} else {
+					lines = append(lines, prefix)
+					line = line[len(prefix):]
+				}
+			}
+		}
+		// Append the last line, unless the paragraph ended with a
+		// word which was broken between runes
+		if line = trim_line(line); line != "" || len(lines) == start {
+			lines = append(lines, line)
+		}
+	}
+
+	// Truncate the lines with an ellipsis
+	if max > 0 && uint(len(lines)) > max {
+		lines = lines[:max]
+		ellipsis := TEXT_ELLIPSIS
+		if _, err := this.glyph([]rune(ellipsis)[0]); err == gopi.ErrBadParameter {
+			ellipsis = TEXT_ELLIPSIS_FALLBACK
+		} else if err != nil {
+			return nil, err
+		}
+		if line, err := this.prefix(lines[max-1], width, ellipsis); err != nil {
+			return nil, err
+		} else {
+			lines[max-1] = line + ellipsis
+		}
+	}
+
+	// Return the lines
+	return lines, nil
+}
+
+// fits returns true if a line without trailing whitespace is no wider
+// than a width
+func (this *face) fits(line string, width float32) (bool, error) {
+	if advance, err := this.advance(trim_line(line)); err != nil {
+		return false, err
+	} else {
+		return advance <= width, nil
+	}
+}
+
+// prefix returns the longest prefix of a line which fits into a width with
+// a suffix, which is at least one rune when there is no suffix
+func (this *face) prefix(line string, width float32, suffix string) (string, error) {
+	runes := []rune(trim_line(line))
+	for i := len(runes); i > 0; i-- {
+		prefix := trim_line(string(runes[:i]))
+		if width == 0 && suffix != "" {
+			return prefix, nil
+		} else if fits, err := this.fits(prefix+suffix, width); err != nil {
+			return "", err
+		} else if fits {
+			return prefix, nil
+		}
+	}
+	if suffix == "" && len(runes) > 0 {
+		return string(runes[:1]), nil
+	} else {
+		return "", nil
+	}
+}
+
+// segments splits a paragraph into segments which end where the line
+// can be broken, which is after whitespace or a hyphen, or before and
+// after ideographic characters
+func segments(paragraph string) []string {
+	segments := make([]string, 0)
+	runes := []rune(paragraph)
+	start := 0
+	for i := 0; i < len(runes)-1; i++ {
+		value, next := runes[i], runes[i+1]
+		if unicode.IsSpace(next) {
+			continue
+		} else if unicode.IsSpace(value) || value == '-' || value == '‐' || is_ideographic(value) || is_ideographic(next) {
+			segments = append(segments, string(runes[start:i+1]))
+			start = i + 1
+		}
+	}
+	if start < len(runes) {
+		segments = append(segments, string(runes[start:]))
+	}
+	return segments
+}
+
+func is_ideographic(value rune) bool {
+	return unicode.In(value, unicode.Han, unicode.Hiragana, unicode.Katakana, unicode.Hangul)
+}
+
+func trim_line(line string) string {
+	return strings.TrimRightFunc(line, unicode.IsSpace)
+}
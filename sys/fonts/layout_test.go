@@ -0,0 +1,183 @@
+/*
+  Go Language Raspberry Pi Interface
+  (c) Copyright David Thorpe 2016-2019
+  All Rights Reserved
+
+  Documentation http://djthorpe.github.io/gopi/
+  For Licensing and Usage information, please see LICENSE.md
+*/
+
+package fonts_test
+
+import (
+	"strings"
+	"testing"
+
+	// Frameworks
+	gopi "github.com/djthorpe/gopi"
+	fonts "github.com/djthorpe/gopi-graphics/sys/fonts"
+)
+
+////////////////////////////////////////////////////////////////////////////////
+// TESTS
+
+func Test_Metrics_000(t *testing.T) {
+	// Metrics scale with the size of the face
+	manager, face := open_face(t)
+	defer manager.Close()
+	layout := manager.(fonts.TextLayout)
+
+	metrics, err := layout.FaceMetrics(face, 20)
+	if err != nil {
+		t.Fatal(err)
+	} else if metrics.Ascent <= 0 || metrics.Descent >= 0 {
+		t.Error("Unexpected metrics", metrics)
+	} else if metrics.Height != metrics.Ascent-metrics.Descent+metrics.LineGap {
+		t.Error("Unexpected height", metrics)
+	}
+	if metrics_, err := layout.FaceMetrics(face, 40); err != nil {
+		t.Fatal(err)
+	} else if metrics_.Height < 1.9*metrics.Height || metrics_.Height > 2.1*metrics.Height {
+		t.Error("Expected height to double", metrics, metrics_)
+	}
+	if _, err := layout.FaceMetrics(face, 0); err != gopi.ErrBadParameter {
+		t.Error("Expected ErrBadParameter, got", err)
+	}
+}
+
+func Test_Measure_000(t *testing.T) {
+	// Measure lines of text
+	manager, face := open_face(t)
+	defer manager.Close()
+	layout := manager.(fonts.TextLayout)
+	metrics, err := layout.FaceMetrics(face, 20)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	origin, size, err := layout.MeasureString(face, 20, fonts.TEXT_ALIGN_LEFT|fonts.TEXT_ALIGN_BASELINE, "Hello")
+	if err != nil {
+		t.Fatal(err)
+	} else if origin != (gopi.Point{0, -metrics.Ascent}) {
+		t.Error("Unexpected origin", origin)
+	} else if size.W <= 0 || size.H != metrics.Ascent-metrics.Descent {
+		t.Error("Unexpected size", size)
+	}
+
+	// A second line adds the distance between baselines, and the width
+	// is the widest line
+	origin_, size_, err := layout.MeasureString(face, 20, fonts.TEXT_ALIGN_RIGHT|fonts.TEXT_ALIGN_TOP, "Hi\nHello")
+	if err != nil {
+		t.Fatal(err)
+	} else if origin_ != (gopi.Point{-size.W, 0}) {
+		t.Error("Unexpected origin", origin_)
+	} else if size_ != (gopi.Size{size.W, size.H + metrics.Height}) {
+		t.Error("Unexpected size", size_)
+	}
+}
+
+func Test_Wrap_000(t *testing.T) {
+	// Wrap text into lines
+	manager, face := open_face(t)
+	defer manager.Close()
+	layout := manager.(fonts.TextLayout)
+	word := measure(t, layout, face, "world")
+	hello_world := measure(t, layout, face, "hello world")
+	hyphens := measure(t, layout, face, "hello-hello-")
+
+	tests := []struct {
+		name  string
+		width float32
+		text  string
+		lines []string
+	}{
+		{"empty string", word, "", []string{""}},
+		{"no width", 0, "hello world\nagain", []string{"hello world", "again"}},
+		{"fits", hello_world, "hello world", []string{"hello world"}},
+		{"break at space", word, "hello world", []string{"hello", "world"}},
+		{"break at hyphen", hyphens, "hello-hello-hello", []string{"hello-hello-", "hello"}},
+		{"explicit newlines", hello_world, "hello\n\nworld", []string{"hello", "", "world"}},
+		{"trailing spaces", word, "hello    \nworld   ", []string{"hello", "world"}},
+		{"trailing spaces before break", word, "hello      world", []string{"hello", "world"}},
+		{"only spaces", word, "   ", []string{""}},
+	}
+	for _, test := range tests {
+		if lines, err := layout.WrapString(face, 20, test.width, 0, test.text); err != nil {
+			t.Errorf("%v: %v", test.name, err)
+		} else if strings.Join(lines, "|") != strings.Join(test.lines, "|") {
+			t.Errorf("%v: expected %q, got %q", test.name, test.lines, lines)
+		}
+	}
+
+	if _, err := layout.WrapString(face, 20, -1, 0, "hello"); err != gopi.ErrBadParameter {
+		t.Error("Expected ErrBadParameter, got", err)
+	}
+}
+
+func Test_Wrap_001(t *testing.T) {
+	// A word wider than the line is broken between runes
+	manager, face := open_face(t)
+	defer manager.Close()
+	layout := manager.(fonts.TextLayout)
+	width := measure(t, layout, face, "abcd")
+
+	lines, err := layout.WrapString(face, 20, width, 0, "abcdefghijklmnop qr")
+	if err != nil {
+		t.Fatal(err)
+	} else if len(lines) < 4 {
+		t.Error("Expected at least four lines, got", lines)
+	} else if strings.Join(lines, "") != "abcdefghijklmnopqr" {
+		t.Error("Unexpected lines", lines)
+	}
+	for _, line := range lines {
+		if measure(t, layout, face, line) > width {
+			t.Errorf("Line %q is wider than %v", line, width)
+		}
+	}
+
+	// A line narrower than one rune has one rune on each line
+	if lines, err := layout.WrapString(face, 20, 1, 0, "abc"); err != nil {
+		t.Fatal(err)
+	} else if strings.Join(lines, "|") != "a|b|c" {
+		t.Error("Unexpected lines", lines)
+	}
+}
+
+func Test_Wrap_002(t *testing.T) {
+	// Truncate lines with an ellipsis
+	manager, face := open_face(t)
+	defer manager.Close()
+	layout := manager.(fonts.TextLayout)
+	width := measure(t, layout, face, "hello world")
+
+	if lines, err := layout.WrapString(face, 20, width, 1, "hello world again"); err != nil {
+		t.Fatal(err)
+	} else if len(lines) != 1 || strings.HasSuffix(lines[0], fonts.TEXT_ELLIPSIS) == false {
+		t.Error("Unexpected lines", lines)
+	} else if measure(t, layout, face, lines[0]) > width {
+		t.Errorf("Line %q is wider than %v", lines[0], width)
+	}
+	if lines, err := layout.WrapString(face, 20, width, 2, "hello world again"); err != nil {
+		t.Fatal(err)
+	} else if strings.Join(lines, "|") != "hello world|again" {
+		t.Error("Unexpected lines", lines)
+	}
+	if lines, err := layout.WrapString(face, 20, 0, 1, "hello\nworld"); err != nil {
+		t.Fatal(err)
+	} else if strings.Join(lines, "|") != "hello"+fonts.TEXT_ELLIPSIS {
+		t.Error("Unexpected lines", lines)
+	}
+}
+
+////////////////////////////////////////////////////////////////////////////////
+// PRIVATE METHODS
+
+func measure(t *testing.T, layout fonts.TextLayout, face gopi.FontFace, text string) float32 {
+	t.Helper()
+	if _, size, err := layout.MeasureString(face, 20, fonts.TEXT_ALIGN_LEFT, text); err != nil {
+		t.Fatal(err)
+	} else {
+		return size.W
+	}
+	return 0
+}
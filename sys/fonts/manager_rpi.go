@@ -17,17 +17,27 @@ import (
 
 	// Frameworks
 	"github.com/djthorpe/gopi"
+	surface "github.com/djthorpe/gopi-graphics/sys/surface"
 	ft "github.com/djthorpe/gopi-hw/freetype"
 )
 
 ////////////////////////////////////////////////////////////////////////////////
 // TYPES
 
-type FontManager struct{}
+type FontManager struct {
+	// Resolution used for point sizes when drawing text,
+	// which defaults to 72 pixels per inch
+	PixelsPerInch uint
+
+	// Size of the glyph cache in bytes, which defaults to 1MB
+	GlyphCacheSize uint
+}
 
 type manager struct {
 	log                 gopi.Logger
 	library             ft.FT_Library
+	cache               *glyph_cache
+	ppi                 uint
 	major, minor, patch int
 	faces               map[string]gopi.FontFace
 	sync.Mutex
@@ -36,17 +46,99 @@ type manager struct {
 type face struct {
 	handle ft.FT_Face
 	path   string
+	points float32
+	ppi    uint
+	cache  *glyph_cache
+	sync.Mutex
+}
+
+// Face is implemented by faces returned by the font manager
+// in addition to gopi.FontFace, in order to render glyphs
+type Face interface {
+	gopi.FontFace
+
+	// Set the size in points at a resolution in pixels per inch,
+	// or the size in pixels when the resolution is zero
+	SetSize(points float32, ppi uint) error
+
+	// Return the size and resolution set on the face
+	Size() (float32, uint)
+
+	// Render the glyph for a rune at the current size
+	GlyphForRune(rune) (*Glyph, error)
+
+	// Return the metrics for the face at the current size
+	Metrics() (FaceMetrics, error)
+}
+
+// Glyph is a rendered glyph with eight bits of coverage per pixel,
+// where the first row of coverage is the top of the glyph. The bearing
+// is the offset from the pen position on the baseline to the top left
+// of the glyph, with positive Y upwards, and the advance is the distance
+// to the pen position of the next glyph
+type Glyph struct {
+	Rune          rune
+	Index         uint
+	Width, Height uint
+	Pitch         uint
+	Bearing       gopi.Point
+	Advance       gopi.Point
+	Coverage      []byte
 }
 
+// Renderer is implemented by the font manager to draw text into
+// bitmaps created by the surface manager
+type Renderer interface {
+	// Draw text with a face at a point size, where the origin is
+	// positioned relative to the text by the alignment. Lines of
+	// text are separated by newlines
+	DrawString(bitmap gopi.Bitmap, face gopi.FontFace, size float32, color gopi.Color, origin gopi.Point, align TextAlign, text string) error
+}
+
+// TextAlign determines the position of text relative to the origin
+type TextAlign uint
+
+////////////////////////////////////////////////////////////////////////////////
+// CONSTANTS
+
+const (
+	// Horizontal alignment
+	TEXT_ALIGN_LEFT   TextAlign = 0x00
+	TEXT_ALIGN_CENTER TextAlign = 0x01
+	TEXT_ALIGN_RIGHT  TextAlign = 0x02
+	TEXT_ALIGN_HMASK  TextAlign = 0x0F
+
+	// Vertical alignment, where the default is the baseline
+	// of the first line of text
+	TEXT_ALIGN_BASELINE TextAlign = 0x00
+	TEXT_ALIGN_TOP      TextAlign = 0x10
+	TEXT_ALIGN_MIDDLE   TextAlign = 0x20
+	TEXT_ALIGN_BOTTOM   TextAlign = 0x30
+	TEXT_ALIGN_VMASK    TextAlign = 0xF0
+)
+
+const (
+	FONT_PIXELS_PER_INCH_DEFAULT  = 72
+	FONT_GLYPH_CACHE_SIZE_DEFAULT = 1024 * 1024
+)
+
 ////////////////////////////////////////////////////////////////////////////////
 // OPEN AND CLOSE
 
 func (config FontManager) Open(log gopi.Logger) (gopi.Driver, error) {
-	log.Debug("<graphics.fonts.Open>{ }")
+	log.Debug("<graphics.fonts.Open>{ ppi=%v glyph_cache_size=%v }", config.PixelsPerInch, config.GlyphCacheSize)
 
 	this := new(manager)
 	this.log = log
 	this.faces = make(map[string]gopi.FontFace, 0)
+	if this.ppi = config.PixelsPerInch; this.ppi == 0 {
+		this.ppi = FONT_PIXELS_PER_INCH_DEFAULT
+	}
+	if config.GlyphCacheSize == 0 {
+		this.cache = new_glyph_cache(FONT_GLYPH_CACHE_SIZE_DEFAULT)
+	} else {
+		this.cache = new_glyph_cache(config.GlyphCacheSize)
+	}
 
 	this.Lock()
 	defer this.Unlock()
@@ -86,6 +178,7 @@ func (this *manager) Close() error {
 	// Release resources
 	this.library = nil
 	this.faces = nil
+	this.cache = nil
 	return nil
 }
 
@@ -93,7 +186,7 @@ func (this *manager) Close() error {
 // STRINGIFY
 
 func (this *manager) String() string {
-	return fmt.Sprintf("<graphics.fonts.Manager>{ handle=0x%X version={%v,%v,%v} }", this.library, this.major, this.minor, this.patch)
+	return fmt.Sprintf("<graphics.fonts.Manager>{ handle=0x%X version={%v,%v,%v} ppi=%v }", this.library, this.major, this.minor, this.patch, this.ppi)
 }
 
 ////////////////////////////////////////////////////////////////////////////////
@@ -108,7 +201,8 @@ func (this *manager) OpenFaceAtIndex(path string, index uint) (gopi.FontFace, er
 
 	// Create the face
 	face := &face{
-		path: filepath.Clean(path),
+		path:  filepath.Clean(path),
+		cache: this.cache,
 	}
 
 	this.Lock()
@@ -189,6 +283,7 @@ func (this *manager) DestroyFace(f gopi.FontFace) error {
 		return gopi.ErrBadParameter
 	} else {
 		delete(this.faces, face_.path)
+		face_.cache.Invalidate(face_)
 		return ft.FT_DoneFace(face_.handle)
 	}
 }
@@ -234,3 +329,36 @@ func (this *manager) Faces(family string, flags gopi.FontFlags) []gopi.FontFace
 	}
 	return faces
 }
+
+////////////////////////////////////////////////////////////////////////////////
+// GLYPH CACHE
+
+func (this *manager) GlyphCacheStats() GlyphCacheStats {
+	this.Lock()
+	defer this.Unlock()
+
+	if this.cache == nil {
+		return GlyphCacheStats{}
+	} else {
+		return this.cache.Stats()
+	}
+}
+
+////////////////////////////////////////////////////////////////////////////////
+// RENDER
+
+func (this *manager) DrawString(bitmap gopi.Bitmap, f gopi.FontFace, size float32, color gopi.Color, origin gopi.Point, align TextAlign, text string) error {
+	this.log.Debug2("<graphics.fonts>DrawString{ face=%v size=%v color=%v origin=%v align=%v text=%v }", f, size, color, origin, align, text)
+
+	if bitmap_, ok := bitmap.(surface.MaskBitmap); ok == false {
+		return gopi.ErrNotImplemented
+	} else if face_, ok := f.(*face); ok == false {
+		return gopi.ErrBadParameter
+	} else if mask, err := face_.mask(size, this.ppi, align, text); err != nil {
+		return err
+	} else if mask.Bounds().Empty() {
+		return nil
+	} else {
+		return bitmap_.FillMaskToColor(origin, mask, color)
+	}
+}
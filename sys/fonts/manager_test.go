@@ -0,0 +1,128 @@
+// +build !rpi
+
+/*
+  Go Language Raspberry Pi Interface
+  (c) Copyright David Thorpe 2016-2019
+  All Rights Reserved
+
+  Documentation http://djthorpe.github.io/gopi/
+  For Licensing and Usage information, please see LICENSE.md
+*/
+
+package fonts_test
+
+import (
+	"image"
+	"image/color"
+	"math"
+	"testing"
+
+	// Frameworks
+	gopi "github.com/djthorpe/gopi"
+	display "github.com/djthorpe/gopi-graphics/sys/display"
+	fonts "github.com/djthorpe/gopi-graphics/sys/fonts"
+	logger "github.com/djthorpe/gopi-graphics/sys/internal/logger"
+	surface "github.com/djthorpe/gopi-graphics/sys/surface"
+)
+
+////////////////////////////////////////////////////////////////////////////////
+// TESTS
+
+func Test_DrawString_000(t *testing.T) {
+	// Draw text with each alignment, and check where it is drawn
+	manager, face := open_face(t)
+	defer manager.Close()
+	gfx := open_graphics(t)
+	defer gfx.Close()
+	if err := face.SetSize(20, 0); err != nil {
+		t.Fatal(err)
+	}
+	metrics, err := face.Metrics()
+	if err != nil {
+		t.Fatal(err)
+	}
+	ascent := int(math.Floor(float64(metrics.Ascent) + 0.5))
+
+	tests := []struct {
+		origin gopi.Point
+		align  fonts.TextAlign
+		check  func(image.Rectangle) bool
+	}{
+		// Top left of the text is at the origin, with the baseline
+		// at the ascent below it
+		{gopi.Point{4, 4}, fonts.TEXT_ALIGN_LEFT | fonts.TEXT_ALIGN_TOP, func(r image.Rectangle) bool {
+			return r.Min.X >= 4 && r.Min.X <= 6 && r.Min.Y >= 4 && r.Max.Y == 4+ascent
+		}},
+		// Text sits on the baseline
+		{gopi.Point{4, 28}, fonts.TEXT_ALIGN_LEFT | fonts.TEXT_ALIGN_BASELINE, func(r image.Rectangle) bool {
+			return r.Min.X >= 4 && r.Max.Y == 28
+		}},
+		// Text ends at the origin
+		{gopi.Point{60, 28}, fonts.TEXT_ALIGN_RIGHT | fonts.TEXT_ALIGN_BASELINE, func(r image.Rectangle) bool {
+			return r.Max.X <= 60 && r.Max.X >= 58 && r.Max.Y == 28
+		}},
+		// Text is centered on the origin
+		{gopi.Point{32, 16}, fonts.TEXT_ALIGN_CENTER | fonts.TEXT_ALIGN_MIDDLE, func(r image.Rectangle) bool {
+			return abs(r.Min.X+r.Max.X-64) <= 2 && abs(r.Min.Y+r.Max.Y-32) <= 4
+		}},
+	}
+	for _, test := range tests {
+		bitmap, err := gfx.CreateBitmap(gopi.SURFACE_FLAG_BITMAP|gopi.SURFACE_FLAG_RGBA32, gopi.Size{64, 32})
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer gfx.DestroyBitmap(bitmap)
+		if err := manager.(fonts.Renderer).DrawString(bitmap, face, 20, gopi.Color{1, 1, 1, 1}, test.origin, test.align, "HH"); err != nil {
+			t.Fatal(err)
+		} else if bounds, opaque := drawn(t, bitmap); opaque == false {
+			t.Errorf("align=%v: expected fully covered pixels", test.align)
+		} else if test.check(bounds) == false {
+			t.Errorf("align=%v: unexpected bounds %v for origin %v", test.align, bounds, test.origin)
+		}
+	}
+}
+
+////////////////////////////////////////////////////////////////////////////////
+// PRIVATE METHODS
+
+func open_graphics(t *testing.T) gopi.SurfaceManager {
+	t.Helper()
+	if d, err := gopi.Open(display.VirtualDisplay{Width: 64, Height: 32}, logger.New(t)); err != nil {
+		t.Fatal(err)
+	} else if gfx, err := gopi.Open(surface.SurfaceManager{Display: d.(gopi.Display)}, logger.New(t)); err != nil {
+		t.Fatal(err)
+	} else {
+		return gfx.(gopi.SurfaceManager)
+	}
+	return nil
+}
+
+// drawn returns the bounds of the pixels which have been drawn,
+// and whether any pixels are fully covered by the text color
+func drawn(t *testing.T, bitmap gopi.Bitmap) (image.Rectangle, bool) {
+	t.Helper()
+	img, err := bitmap.(surface.PixelBitmap).ReadPixels(gopi.ZeroPoint, bitmap.Size())
+	if err != nil {
+		t.Fatal(err)
+	}
+	bounds, opaque := image.ZR, false
+	for y := img.Rect.Min.Y; y < img.Rect.Max.Y; y++ {
+		for x := img.Rect.Min.X; x < img.Rect.Max.X; x++ {
+			if pixel := img.NRGBAAt(x, y); pixel.A == 0 {
+				continue
+			} else if pixel == (color.NRGBA{0xFF, 0xFF, 0xFF, 0xFF}) {
+				opaque = true
+			}
+			bounds = bounds.Union(image.Rect(x, y, x+1, y+1))
+		}
+	}
+	return bounds, opaque
+}
+
+func abs(value int) int {
+	if value < 0 {
+		return -value
+	} else {
+		return value
+	}
+}
@@ -0,0 +1,77 @@
+/*
+  Go Language Raspberry Pi Interface
+  (c) Copyright David Thorpe 2016-2019
+  All Rights Reserved
+
+  Documentation http://djthorpe.github.io/gopi/
+  For Licensing and Usage information, please see LICENSE.md
+*/
+
+// Package logger implements a gopi.Logger for tests, which discards
+// debugging output, writes other messages to the test log and fails the
+// test on errors
+package logger
+
+import (
+	"fmt"
+	"testing"
+
+	// Frameworks
+	gopi "github.com/djthorpe/gopi"
+)
+
+////////////////////////////////////////////////////////////////////////////////
+// TYPES
+
+type logger struct {
+	t testing.TB
+}
+
+////////////////////////////////////////////////////////////////////////////////
+// NEW
+
+// New returns a logger which writes to the log of a test
+func New(t testing.TB) gopi.Logger {
+	return &logger{t}
+}
+
+////////////////////////////////////////////////////////////////////////////////
+// IMPLEMENTATION
+
+func (this *logger) Close() error {
+	return nil
+}
+
+func (this *logger) Fatal(format string, v ...interface{}) error {
+	err := fmt.Errorf(format, v...)
+	this.t.Errorf("FATAL: %v", err)
+	return err
+}
+
+func (this *logger) Error(format string, v ...interface{}) error {
+	err := fmt.Errorf(format, v...)
+	this.t.Errorf("ERROR: %v", err)
+	return err
+}
+
+func (this *logger) Warn(format string, v ...interface{}) {
+	this.t.Logf("WARN: "+format, v...)
+}
+
+func (this *logger) Info(format string, v ...interface{}) {
+	this.t.Logf("INFO: "+format, v...)
+}
+
+func (this *logger) Debug(format string, v ...interface{})  {}
+func (this *logger) Debug2(format string, v ...interface{}) {}
+
+func (this *logger) IsDebug() bool {
+	return false
+}
+
+////////////////////////////////////////////////////////////////////////////////
+// STRINGIFY
+
+func (this *logger) String() string {
+	return "<graphics.logger>{ test=" + this.t.Name() + " }"
+}
@@ -0,0 +1,168 @@
+// +build !rpi
+
+/*
+  Go Language Raspberry Pi Interface
+  (c) Copyright David Thorpe 2019
+  All Rights Reserved
+
+  Documentation http://djthorpe.github.io/gopi/
+  For Licensing and Usage information, please see LICENSE.md
+*/
+
+package sprites_test
+
+import (
+	"image/color"
+	"strings"
+	"testing"
+
+	// Frameworks
+	gopi "github.com/djthorpe/gopi"
+	display "github.com/djthorpe/gopi-graphics/sys/display"
+	logger "github.com/djthorpe/gopi-graphics/sys/internal/logger"
+	sprites "github.com/djthorpe/gopi-graphics/sys/sprites"
+	surface "github.com/djthorpe/gopi-graphics/sys/surface"
+)
+
+////////////////////////////////////////////////////////////////////////////////
+// TESTS
+
+func Test_Bitmap_000(t *testing.T) {
+	// Create a bitmap for a sprite and check the pixels
+	gfx := open_graphics(t)
+	defer gfx.Close()
+	manager := open_manager(t, gfx)
+	defer manager.Close()
+	sprite := open_sprite(t, manager, "[arrow]\nb = black\nw = #FFFFFF80\nBw\n.w\n")
+
+	bitmap, err := manager.(sprites.SpriteBitmaps).BitmapForSprite(sprite)
+	if err != nil {
+		t.Fatal(err)
+	} else if bitmap.Type() != gopi.SURFACE_FLAG_RGBA32 {
+		t.Error("Unexpected type", bitmap.Type())
+	} else if bitmap_, err := manager.(sprites.SpriteBitmaps).BitmapForSprite(sprite); err != nil {
+		t.Fatal(err)
+	} else if bitmap_ != bitmap {
+		t.Error("Expected bitmap to be cached")
+	}
+
+	img, err := bitmap.(surface.PixelBitmap).ReadPixels(gopi.ZeroPoint, bitmap.Size())
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, pixel := range []struct {
+		x, y  int
+		color color.NRGBA
+	}{
+		{0, 0, color.NRGBA{0x00, 0x00, 0x00, 0xFF}},
+		{1, 0, color.NRGBA{0xFF, 0xFF, 0xFF, 0x80}},
+		{0, 1, color.NRGBA{0x00, 0x00, 0x00, 0x00}},
+		{1, 1, color.NRGBA{0xFF, 0xFF, 0xFF, 0x80}},
+	} {
+		if c := img.NRGBAAt(pixel.x, pixel.y); c != pixel.color {
+			t.Errorf("Pixel at %v,%v: expected %v, got %v", pixel.x, pixel.y, pixel.color, c)
+		}
+	}
+}
+
+func Test_Bitmap_001(t *testing.T) {
+	// Removing a sprite destroys the bitmap, or destroys it later
+	// if it is on a surface
+	gfx := open_graphics(t)
+	defer gfx.Close()
+	manager := open_manager(t, gfx)
+	defer manager.Close()
+	resources := gfx.(surface.Resources)
+
+	// Remove a sprite which is not on a surface
+	sprite := open_sprite(t, manager, "[a]\nb = black\nb\n")
+	if _, err := manager.(sprites.SpriteBitmaps).BitmapForSprite(sprite); err != nil {
+		t.Fatal(err)
+	} else if bitmaps := resources.Bitmaps(); len(bitmaps) != 1 {
+		t.Fatal("Unexpected bitmaps", bitmaps)
+	} else if err := manager.RemoveSprite("a"); err != nil {
+		t.Fatal(err)
+	} else if bitmaps := resources.Bitmaps(); len(bitmaps) != 0 {
+		t.Error("Expected bitmap to be destroyed", bitmaps)
+	}
+
+	// Remove a sprite which is on a surface, and then destroy the surface
+	var s gopi.Surface
+	sprite = open_sprite(t, manager, "[b]\nb = black\nb\n")
+	if bitmap, err := manager.(sprites.SpriteBitmaps).BitmapForSprite(sprite); err != nil {
+		t.Fatal(err)
+	} else if err := gfx.Do(func(gfx gopi.SurfaceManager) error {
+		var err error
+		s, err = gfx.CreateSurfaceWithBitmap(bitmap, 0, 1.0, gopi.SURFACE_LAYER_DEFAULT, gopi.ZeroPoint, bitmap.Size())
+		return err
+	}); err != nil {
+		t.Fatal(err)
+	} else if err := manager.RemoveSprite("b"); err != nil {
+		t.Fatal(err)
+	} else if bitmaps := resources.Bitmaps(); len(bitmaps) != 1 {
+		t.Error("Expected bitmap to be retained", bitmaps)
+	} else if err := gfx.Do(func(gfx gopi.SurfaceManager) error {
+		return gfx.DestroySurface(s)
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	// The retired bitmap is destroyed with the next sprite to be removed
+	open_sprite(t, manager, "[c]\nb = black\nb\n")
+	if err := manager.RemoveSprite("c"); err != nil {
+		t.Fatal(err)
+	} else if bitmaps := resources.Bitmaps(); len(bitmaps) != 0 {
+		t.Error("Expected bitmap to be destroyed", bitmaps)
+	}
+}
+
+func Test_Bitmap_002(t *testing.T) {
+	// Closing the manager destroys bitmaps, except those which are
+	// on a surface
+	gfx := open_graphics(t)
+	defer gfx.Close()
+	manager := open_manager(t, gfx)
+	resources := gfx.(surface.Resources)
+
+	if bitmap, err := manager.(sprites.SpriteBitmaps).BitmapForSprite(open_sprite(t, manager, "[a]\nb = black\nb\n")); err != nil {
+		t.Fatal(err)
+	} else if err := gfx.Do(func(gfx gopi.SurfaceManager) error {
+		_, err := gfx.CreateSurfaceWithBitmap(bitmap, 0, 1.0, gopi.SURFACE_LAYER_DEFAULT, gopi.ZeroPoint, bitmap.Size())
+		return err
+	}); err != nil {
+		t.Fatal(err)
+	} else if _, err := manager.(sprites.SpriteBitmaps).BitmapForSprite(open_sprite(t, manager, "[b]\nb = black\nb\n")); err != nil {
+		t.Fatal(err)
+	} else if err := manager.Close(); err != nil {
+		t.Error(err)
+	} else if bitmaps := resources.Bitmaps(); len(bitmaps) != 1 {
+		t.Error("Unexpected bitmaps", bitmaps)
+	}
+}
+
+////////////////////////////////////////////////////////////////////////////////
+// PRIVATE METHODS
+
+func open_graphics(t *testing.T) gopi.SurfaceManager {
+	t.Helper()
+	if d, err := gopi.Open(display.VirtualDisplay{Width: 16, Height: 16}, logger.New(t)); err != nil {
+		t.Fatal(err)
+	} else if gfx, err := gopi.Open(surface.SurfaceManager{Display: d.(gopi.Display)}, logger.New(t)); err != nil {
+		t.Fatal(err)
+	} else {
+		return gfx.(gopi.SurfaceManager)
+	}
+	return nil
+}
+
+func open_sprite(t *testing.T, manager gopi.SpriteManager, text string) gopi.Sprite {
+	t.Helper()
+	if sprites, err := manager.OpenSprites(strings.NewReader(text)); err != nil {
+		t.Fatal(err)
+	} else if len(sprites) != 1 {
+		t.Fatal("Expected one sprite, got", sprites)
+	} else {
+		return sprites[0]
+	}
+	return nil
+}
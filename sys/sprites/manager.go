@@ -14,9 +14,13 @@ import (
 	"io"
 	"os"
 	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
 
 	// Frameworks
 	gopi "github.com/djthorpe/gopi"
+	surface "github.com/djthorpe/gopi-graphics/sys/surface"
 	"github.com/djthorpe/gopi/util/errors"
 )
 
@@ -30,8 +34,53 @@ type SpriteManager struct {
 type manager struct {
 	log      gopi.Logger
 	graphics gopi.SurfaceManager
+	sprites  map[string]gopi.Sprite
+	files    map[string][]string
+	bitmaps  map[gopi.Sprite]gopi.Bitmap
+
+	// Bitmaps for removed sprites which were still on a surface
+	retired []gopi.Bitmap
+
+	sync.Mutex
+}
+
+// SpriteRegistry is implemented by the sprite manager in addition
+// to gopi.SpriteManager
+type SpriteRegistry interface {
+	gopi.SpriteManager
+
+	// Remove a loaded sprite by name
+	RemoveSprite(name string) error
+
+	// Re-open the files loaded by OpenSpritesAtPath, replacing
+	// the sprites which were loaded from them
+	ReloadSprites() error
+}
+
+// DuplicateError is returned when a sprite has the same name as a sprite
+// which is already loaded, where the source is the path of the file the
+// loaded sprite was read from, or empty if it was read from a stream
+type DuplicateError struct {
+	Name, Source string
 }
 
+// SpriteBitmaps is implemented by the sprite manager to return
+// sprites as bitmaps which can be placed on a surface
+type SpriteBitmaps interface {
+	// Return a bitmap for a sprite, which is created on first use
+	BitmapForSprite(gopi.Sprite) (gopi.Bitmap, error)
+}
+
+////////////////////////////////////////////////////////////////////////////////
+// CONSTANTS
+
+const (
+	// The name for unnamed sprites which are not read from a file. Each
+	// stream has a different name, so later streams are named sprite2,
+	// sprite3 and so forth
+	SPRITE_NAME_DEFAULT = "sprite"
+)
+
 ////////////////////////////////////////////////////////////////////////////////
 // OPEN AND CLOSE
 
@@ -41,6 +90,9 @@ func (config SpriteManager) Open(log gopi.Logger) (gopi.Driver, error) {
 	this := new(manager)
 	this.log = log
 	this.graphics = config.Graphics
+	this.sprites = make(map[string]gopi.Sprite, 0)
+	this.files = make(map[string][]string, 0)
+	this.bitmaps = make(map[gopi.Sprite]gopi.Bitmap, 0)
 
 	return this, nil
 }
@@ -48,11 +100,32 @@ func (config SpriteManager) Open(log gopi.Logger) (gopi.Driver, error) {
 func (this *manager) Close() error {
 	this.log.Debug("<graphics.sprites>Close{ graphics=%v }", this.graphics)
 
+	this.Lock()
+	defer this.Unlock()
+
+	// Free bitmaps. Bitmaps which are still drawn by a surface are
+	// freed by the graphics manager with the surface
+	errs := new(errors.CompoundError)
+	if this.graphics != nil {
+		for _, bitmap := range this.bitmaps {
+			this.retired = append(this.retired, bitmap)
+		}
+		for _, bitmap := range this.retired {
+			if err := this.graphics.DestroyBitmap(bitmap); err != nil && err != surface.ErrBitmapInUse {
+				errs.Add(err)
+			}
+		}
+	}
+
 	// Free resources
 	this.graphics = nil
+	this.sprites = nil
+	this.files = nil
+	this.bitmaps = nil
+	this.retired = nil
 
-	// Return success
-	return nil
+	// Return any errors
+	return errs.ErrorOrSelf()
 }
 
 ////////////////////////////////////////////////////////////////////////////////
@@ -65,8 +138,6 @@ func (this *manager) OpenSpritesAtPath(path string, callback func(manager gopi.S
 	}
 
 	errs := new(errors.CompoundError)
-	fmt.Printf("XX=%v\n", errs == nil)
-
 	errs.Add(filepath.Walk(path, func(path string, info os.FileInfo, err error) error {
 		if info == nil {
 			return nil
@@ -81,32 +152,302 @@ func (this *manager) OpenSpritesAtPath(path string, callback func(manager gopi.S
 		if info.IsDir() {
 			return nil
 		}
-		// Open sprite file
-		if handle, err_ := os.Open(path); err_ != nil {
-			// Allow execution to continue
+		// Open sprite file, allowing execution to continue on error
+		if _, err_ := this.open_file(path); err_ != nil {
 			errs.Add(fmt.Errorf("%v: %v", path, err_))
-		} else {
-			defer handle.Close()
-			if _, err_ := this.OpenSprites(handle); err_ != nil {
-				// Allow execution to continue
-				errs.Add(fmt.Errorf("%v: %v", path, err_))
-			}
 		}
 		// Success
 		return nil
 	}))
 
-	fmt.Println(errs)
-
 	return errs.ErrorOrSelf()
 }
 
 // Open one or more sprites from a stream and return them
-func (this *manager) OpenSprites(io.Reader) ([]gopi.Sprite, error) {
-	return nil, gopi.ErrNotImplemented
+func (this *manager) OpenSprites(r io.Reader) ([]gopi.Sprite, error) {
+	return this.open_sprites(r, this.default_name(), "")
 }
 
-// Return loaded sprites, or a specific sprite
+// Return loaded sprites, or a specific sprite. The name can be
+// empty to return all sprites, or a glob pattern such as "pointer_*".
+// Sprites are returned in name order
 func (this *manager) Sprites(name string) []gopi.Sprite {
+	this.Lock()
+	defer this.Unlock()
+
+	sprites := make([]gopi.Sprite, 0)
+	if sprite, exists := this.sprites[name]; exists {
+		return append(sprites, sprite)
+	}
+	for key, sprite := range this.sprites {
+		if name == "" {
+			sprites = append(sprites, sprite)
+		} else if match, _ := filepath.Match(name, key); match {
+			sprites = append(sprites, sprite)
+		}
+	}
+	sort.Slice(sprites, func(i, j int) bool {
+		return sprites[i].Name() < sprites[j].Name()
+	})
+	return sprites
+}
+
+// Remove a loaded sprite by name
+func (this *manager) RemoveSprite(name string) error {
+	this.log.Debug2("<graphics.sprites>RemoveSprite{ name=%v }", name)
+
+	this.Lock()
+	defer this.Unlock()
+
+	if sprite, exists := this.sprites[name]; exists == false {
+		return gopi.ErrBadParameter
+	} else {
+		this.release_bitmap(sprite)
+		delete(this.sprites, name)
+	}
+
+	// Remove the sprite from the file it was loaded from
+	for path, names := range this.files {
+		for i, name_ := range names {
+			if name_ == name {
+				this.files[path] = append(names[:i], names[i+1:]...)
+				break
+			}
+		}
+	}
+
+	// Success
 	return nil
 }
+
+// Re-open the files loaded by OpenSpritesAtPath. Files which no longer
+// exist have their sprites removed, and files which cannot be parsed
+// retain the sprites previously loaded from them
+func (this *manager) ReloadSprites() error {
+	this.log.Debug2("<graphics.sprites>ReloadSprites{ }")
+
+	this.Lock()
+	paths := make([]string, 0, len(this.files))
+	for path := range this.files {
+		paths = append(paths, path)
+	}
+	this.Unlock()
+	sort.Strings(paths)
+
+	errs := new(errors.CompoundError)
+	for _, path := range paths {
+		if _, err := this.open_file(path); os.IsNotExist(err) {
+			this.remove_file(path)
+		} else if err != nil {
+			errs.Add(fmt.Errorf("%v: %v", path, err))
+		}
+	}
+
+	return errs.ErrorOrSelf()
+}
+
+////////////////////////////////////////////////////////////////////////////////
+// BITMAPS
+
+// Return a bitmap for a sprite. The bitmap is created on first use in
+// the best pixel format the surface manager supports, and is destroyed
+// when the sprite is removed or reloaded. A bitmap which is still on a
+// surface at that point is destroyed once it is no longer in use, or
+// when the sprite manager is closed
+func (this *manager) BitmapForSprite(s gopi.Sprite) (gopi.Bitmap, error) {
+	this.log.Debug2("<graphics.sprites>BitmapForSprite{ sprite=%v }", s)
+
+	this.Lock()
+	defer this.Unlock()
+
+	if this.graphics == nil {
+		return nil, gopi.ErrOutOfOrder
+	} else if bitmap, exists := this.bitmaps[s]; exists {
+		return bitmap, nil
+	} else if sprite_, ok := s.(*sprite); ok == false {
+		return nil, gopi.ErrBadParameter
+	} else if bitmap, err := this.create_bitmap(sprite_); err != nil {
+		return nil, err
+	} else {
+		this.bitmaps[s] = bitmap
+		return bitmap, nil
+	}
+}
+
+func (this *manager) create_bitmap(s *sprite) (gopi.Bitmap, error) {
+	loader, ok := this.graphics.(surface.BitmapLoader)
+	if ok == false {
+		return nil, gopi.ErrNotImplemented
+	}
+
+	// Choose the format with the best color depth, and write the
+	// sprite into the bitmap in one go
+	for _, config := range []gopi.SurfaceFlags{gopi.SURFACE_FLAG_RGBA32, gopi.SURFACE_FLAG_RGB888, gopi.SURFACE_FLAG_RGB565} {
+		if bitmap, err := loader.CreateBitmapFromImage(gopi.SURFACE_FLAG_BITMAP|config, s.image); err == gopi.ErrNotImplemented {
+			continue
+		} else if err != nil {
+			return nil, err
+		} else {
+			return bitmap, nil
+		}
+	}
+
+	// No format is supported
+	return nil, gopi.ErrNotImplemented
+}
+
+// release_bitmap destroys the bitmap for a sprite which is being removed,
+// or retires it if it is on a surface. Retired bitmaps which are no longer
+// in use are destroyed at the same time
+func (this *manager) release_bitmap(s gopi.Sprite) {
+	if bitmap, exists := this.bitmaps[s]; exists {
+		delete(this.bitmaps, s)
+		this.retired = append(this.retired, bitmap)
+	}
+	if this.graphics == nil {
+		return
+	}
+	retired := this.retired[:0]
+	for _, bitmap := range this.retired {
+		if err := this.graphics.DestroyBitmap(bitmap); err == surface.ErrBitmapInUse {
+			retired = append(retired, bitmap)
+		} else if err != nil {
+			this.log.Warn("<graphics.sprites>DestroyBitmap: %v", err)
+		}
+	}
+	this.retired = retired
+}
+
+////////////////////////////////////////////////////////////////////////////////
+// STRINGIFY
+
+func (this *DuplicateError) Error() string {
+	if this.Source == "" {
+		return fmt.Sprintf("Duplicate sprite name: %v (loaded from a stream)", this.Name)
+	} else {
+		return fmt.Sprintf("Duplicate sprite name: %v (loaded from %v)", this.Name, this.Source)
+	}
+}
+
+////////////////////////////////////////////////////////////////////////////////
+// PRIVATE METHODS
+
+func (this *manager) open_file(path string) ([]gopi.Sprite, error) {
+	if handle, err := os.Open(path); err != nil {
+		return nil, err
+	} else {
+		defer handle.Close()
+		return this.open_sprites(handle, name_from_path(path), path)
+	}
+}
+
+// open_sprites parses sprites from a stream and adds them to the
+// loaded sprites. When loaded from a file, any sprites previously
+// loaded from the same file are replaced
+func (this *manager) open_sprites(r io.Reader, name, path string) ([]gopi.Sprite, error) {
+	this.log.Debug2("<graphics.sprites>OpenSprites{ name=%v path=%v }", name, path)
+
+	sprites, err := parse(r, name)
+	if err != nil {
+		return nil, err
+	}
+
+	this.Lock()
+	defer this.Unlock()
+
+	// Sprites previously loaded from the same file can be replaced
+	replace := make(map[string]bool, 0)
+	if path != "" {
+		for _, name := range this.files[path] {
+			replace[name] = true
+		}
+	}
+
+	// Check for duplicate names in loaded sprites, where the parser has
+	// already checked for duplicate names in the stream
+	for _, sprite := range sprites {
+		name := sprite.Name()
+		if _, exists := this.sprites[name]; exists && replace[name] == false {
+			return nil, &DuplicateError{name, this.source_of(name)}
+		}
+	}
+
+	// Remove sprites which are being replaced and add the new ones
+	for name := range replace {
+		if sprite, exists := this.sprites[name]; exists {
+			this.release_bitmap(sprite)
+			delete(this.sprites, name)
+		}
+	}
+	for _, sprite := range sprites {
+		this.sprites[sprite.Name()] = sprite
+	}
+	if path != "" {
+		this.files[path] = make([]string, 0, len(sprites))
+		for _, sprite := range sprites {
+			this.files[path] = append(this.files[path], sprite.Name())
+		}
+	}
+
+	// Return the sprites
+	return sprites, nil
+}
+
+// remove_file removes the sprites loaded from a file
+func (this *manager) remove_file(path string) {
+	this.Lock()
+	defer this.Unlock()
+	for _, name := range this.files[path] {
+		if sprite, exists := this.sprites[name]; exists {
+			this.release_bitmap(sprite)
+			delete(this.sprites, name)
+		}
+	}
+	delete(this.files, path)
+}
+
+// default_name returns the name for unnamed sprites in a stream, which is
+// not the name or prefix of any loaded sprite
+func (this *manager) default_name() string {
+	this.Lock()
+	defer this.Unlock()
+	for n := 1; ; n++ {
+		name := SPRITE_NAME_DEFAULT
+		if n > 1 {
+			name = fmt.Sprint(SPRITE_NAME_DEFAULT, n)
+		}
+		if this.name_in_use(name) == false {
+			return name
+		}
+	}
+}
+
+// name_in_use returns true if a loaded sprite has a name, or a name
+// generated from it for further unnamed sprites
+func (this *manager) name_in_use(name string) bool {
+	for key := range this.sprites {
+		if key == name || strings.HasPrefix(key, name+"_") {
+			return true
+		}
+	}
+	return false
+}
+
+// source_of returns the path of the file a sprite was loaded from, or
+// an empty string if it was loaded from a stream
+func (this *manager) source_of(name string) string {
+	for path, names := range this.files {
+		for _, name_ := range names {
+			if name_ == name {
+				return path
+			}
+		}
+	}
+	return ""
+}
+
+// name_from_path returns the filename without the extension
+func name_from_path(path string) string {
+	name := filepath.Base(path)
+	return strings.TrimSuffix(name, filepath.Ext(name))
+}
@@ -0,0 +1,196 @@
+/*
+  Go Language Raspberry Pi Interface
+  (c) Copyright David Thorpe 2019
+  All Rights Reserved
+
+  Documentation http://djthorpe.github.io/gopi/
+  For Licensing and Usage information, please see LICENSE.md
+*/
+
+package sprites_test
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	// Frameworks
+	gopi "github.com/djthorpe/gopi"
+	sprites "github.com/djthorpe/gopi-graphics/sys/sprites"
+)
+
+////////////////////////////////////////////////////////////////////////////////
+// TESTS
+
+func Test_Registry_000(t *testing.T) {
+	// Load sprites from a directory and match names with a glob
+	path := write_sprites(t, map[string]string{
+		"pointer_nw.sprite": "b = black\nBb\n",
+		"pointer_ne.sprite": "b = black\nbB\n",
+		"busy.sprite":       "[busy_1]\nb = black\nb\n\n[busy_2]\nb\n",
+	})
+	defer os.RemoveAll(path)
+	manager := open_manager(t, nil)
+	defer manager.Close()
+	if err := manager.OpenSpritesAtPath(path, open_all); err != nil {
+		t.Fatal(err)
+	}
+
+	if names := sprite_names(manager.Sprites("")); names != "busy_1 busy_2 pointer_ne pointer_nw" {
+		t.Error("Unexpected sprites", names)
+	}
+	if names := sprite_names(manager.Sprites("pointer_*")); names != "pointer_ne pointer_nw" {
+		t.Error("Unexpected sprites", names)
+	}
+	if names := sprite_names(manager.Sprites("busy_?")); names != "busy_1 busy_2" {
+		t.Error("Unexpected sprites", names)
+	}
+	if names := sprite_names(manager.Sprites("pointer_nw")); names != "pointer_nw" {
+		t.Error("Unexpected sprites", names)
+	}
+	if names := sprite_names(manager.Sprites("cursor")); names != "" {
+		t.Error("Unexpected sprites", names)
+	}
+}
+
+func Test_Registry_001(t *testing.T) {
+	// Remove a sprite and then look it up
+	path := write_sprites(t, map[string]string{
+		"pointer.sprite": "b = black\nBb\n",
+	})
+	defer os.RemoveAll(path)
+	manager := open_manager(t, nil)
+	defer manager.Close()
+	if err := manager.OpenSpritesAtPath(path, open_all); err != nil {
+		t.Fatal(err)
+	} else if err := manager.RemoveSprite("pointer"); err != nil {
+		t.Fatal(err)
+	} else if err := manager.RemoveSprite("pointer"); err != gopi.ErrBadParameter {
+		t.Error("Expected ErrBadParameter, got", err)
+	} else if sprites := manager.Sprites("pointer"); len(sprites) != 0 {
+		t.Error("Unexpected sprites", sprites)
+	}
+
+	// Reloading the file restores the sprite
+	if err := manager.ReloadSprites(); err != nil {
+		t.Fatal(err)
+	} else if names := sprite_names(manager.Sprites("")); names != "pointer" {
+		t.Error("Unexpected sprites", names)
+	}
+}
+
+func Test_Registry_002(t *testing.T) {
+	// Reload sprites after the files change
+	path := write_sprites(t, map[string]string{
+		"pointer.sprite": "b = black\nBb\n",
+		"busy.sprite":    "b = black\nb\n",
+	})
+	defer os.RemoveAll(path)
+	manager := open_manager(t, nil)
+	defer manager.Close()
+	if err := manager.OpenSpritesAtPath(path, open_all); err != nil {
+		t.Fatal(err)
+	}
+
+	// Change one file and remove another
+	if err := ioutil.WriteFile(filepath.Join(path, "pointer.sprite"), []byte("[pointer]\nb = black\nbbB\n\n[pointer_2]\nb\n"), 0644); err != nil {
+		t.Fatal(err)
+	} else if err := os.Remove(filepath.Join(path, "busy.sprite")); err != nil {
+		t.Fatal(err)
+	} else if err := manager.ReloadSprites(); err != nil {
+		t.Fatal(err)
+	} else if names := sprite_names(manager.Sprites("")); names != "pointer pointer_2" {
+		t.Error("Unexpected sprites", names)
+	} else if sprite := manager.Sprites("pointer")[0]; sprite.Size() != (gopi.Size{3, 1}) || sprite.Hotspot() != (gopi.Point{2, 0}) {
+		t.Error("Unexpected sprite", sprite)
+	}
+
+	// A file which cannot be parsed retains its sprites
+	if err := ioutil.WriteFile(filepath.Join(path, "pointer.sprite"), []byte("bxb\n"), 0644); err != nil {
+		t.Fatal(err)
+	} else if err := manager.ReloadSprites(); err == nil {
+		t.Error("Expected error reloading sprites")
+	} else if names := sprite_names(manager.Sprites("")); names != "pointer pointer_2" {
+		t.Error("Unexpected sprites", names)
+	}
+}
+
+func Test_Registry_003(t *testing.T) {
+	// Unnamed sprites in each stream have different names
+	manager := open_manager(t, nil)
+	defer manager.Close()
+	for _, text := range []string{"b = black\nb\n", "b = black\nb\n\nb\n", "b = black\nb\n"} {
+		if _, err := manager.OpenSprites(strings.NewReader(text)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if names := sprite_names(manager.Sprites("")); names != "sprite sprite2 sprite2_1 sprite3" {
+		t.Error("Unexpected sprites", names)
+	}
+}
+
+func Test_Registry_004(t *testing.T) {
+	// Duplicate names report where the loaded sprite came from
+	path := write_sprites(t, map[string]string{
+		"pointer.sprite": "b = black\nb\n",
+	})
+	defer os.RemoveAll(path)
+	manager := open_manager(t, nil)
+	defer manager.Close()
+	if err := manager.OpenSpritesAtPath(path, open_all); err != nil {
+		t.Fatal(err)
+	} else if _, err := manager.OpenSprites(strings.NewReader("[busy]\nb = black\nb\n")); err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		name, source string
+	}{
+		{"pointer", filepath.Join(path, "pointer.sprite")},
+		{"busy", ""},
+	}
+	for _, test := range tests {
+		_, err := manager.OpenSprites(strings.NewReader("[" + test.name + "]\nb = black\nb\n"))
+		if err_, ok := err.(*sprites.DuplicateError); ok == false {
+			t.Error(test.name, "Expected DuplicateError, got", err)
+		} else if err_.Name != test.name || err_.Source != test.source {
+			t.Error(test.name, "Unexpected error", err_)
+		}
+	}
+}
+
+////////////////////////////////////////////////////////////////////////////////
+// PRIVATE METHODS
+
+func open_all(gopi.SpriteManager, string, os.FileInfo) bool {
+	return true
+}
+
+// write_sprites creates a temporary directory with sprite files,
+// which should be removed by the caller
+func write_sprites(t *testing.T, files map[string]string) string {
+	t.Helper()
+	path, err := ioutil.TempDir("", "sprites")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for name, text := range files {
+		if err := ioutil.WriteFile(filepath.Join(path, name), []byte(text), 0644); err != nil {
+			os.RemoveAll(path)
+			t.Fatal(err)
+		}
+	}
+	return path
+}
+
+func sprite_names(sprites []gopi.Sprite) string {
+	names := ""
+	for i, sprite := range sprites {
+		if i > 0 {
+			names += " "
+		}
+		names += sprite.Name()
+	}
+	return names
+}
@@ -0,0 +1,355 @@
+/*
+  Go Language Raspberry Pi Interface
+  (c) Copyright David Thorpe 2019
+  All Rights Reserved
+
+  Documentation http://djthorpe.github.io/gopi/
+  For Licensing and Usage information, please see LICENSE.md
+*/
+
+package sprites
+
+import (
+	"bufio"
+	"fmt"
+	"image"
+	"io"
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode"
+
+	// Frameworks
+	gopi "github.com/djthorpe/gopi"
+)
+
+/*
+	A sprite stream consists of one or more sprites, each of which is
+	a grid of pixels followed or preceded by a legend:
+
+	  [name]
+	  ..Bb..
+	  ..bwb.
+
+	  b = black (B = origin)
+	  w = white
+	  . = transparent
+
+	The optional [name] line starts a new sprite, otherwise a grid which
+	follows a blank line or legend starts a new sprite. Names must be
+	unique within the stream. Legend entries map a single character to a
+	color name or a #RRGGBB or #RRGGBBAA value and are retained for
+	subsequent sprites in the stream. The character is followed by
+	whitespace before the equals sign, so that a row of pixels which
+	includes '=' is not mistaken for a legend entry. An
+	uppercase letter whose lowercase letter is in the legend marks the
+	hotspot of the sprite. Lines starting with # are comments.
+*/
+
+////////////////////////////////////////////////////////////////////////////////
+// TYPES
+
+// ParseError is returned when a sprite stream cannot be parsed
+type ParseError struct {
+	Line, Column uint
+	Reason       string
+}
+
+type parser struct {
+	name    string
+	unnamed uint
+	legend  map[rune]gopi.Color
+	names   map[string]bool
+	sprites []gopi.Sprite
+	current *definition
+}
+
+type definition struct {
+	name   string
+	line   uint
+	rows   []row
+	closed bool
+}
+
+type row struct {
+	line   uint
+	pixels []rune
+}
+
+////////////////////////////////////////////////////////////////////////////////
+// GLOBAL VARIABLES
+
+var (
+	legend_colors = map[string]gopi.Color{
+		"transparent": gopi.Color{0, 0, 0, 0},
+		"black":       gopi.Color{0, 0, 0, 1},
+		"white":       gopi.Color{1, 1, 1, 1},
+		"grey":        gopi.Color{0.5, 0.5, 0.5, 1},
+		"gray":        gopi.Color{0.5, 0.5, 0.5, 1},
+		"red":         gopi.Color{1, 0, 0, 1},
+		"green":       gopi.Color{0, 1, 0, 1},
+		"blue":        gopi.Color{0, 0, 1, 1},
+		"yellow":      gopi.Color{1, 1, 0, 1},
+		"cyan":        gopi.Color{0, 1, 1, 1},
+		"magenta":     gopi.Color{1, 0, 1, 1},
+		"purple":      gopi.Color{0.5, 0, 0.5, 1},
+		"orange":      gopi.Color{1, 0.65, 0, 1},
+	}
+
+	// A legend entry is a single character followed by whitespace,
+	// an equals sign and either whitespace or the end of the line
+	legend_line = regexp.MustCompile(`^\s*(\S)\s+(=)(?:\s|$)`)
+)
+
+const (
+	LEGEND_ORIGIN = "origin"
+)
+
+////////////////////////////////////////////////////////////////////////////////
+// PARSE
+
+// parse reads sprites from a stream, naming any sprites without
+// a name line after the name argument
+func parse(r io.Reader, name string) ([]gopi.Sprite, error) {
+	this := &parser{
+		name: name,
+		legend: map[rune]gopi.Color{
+			'.': legend_colors["transparent"],
+		},
+		names:   make(map[string]bool, 0),
+		sprites: make([]gopi.Sprite, 0),
+	}
+
+	scanner := bufio.NewScanner(r)
+	line := uint(0)
+	for scanner.Scan() {
+		line++
+		if err := this.parse_line(line, strings.TrimRightFunc(scanner.Text(), unicode.IsSpace)); err != nil {
+			return nil, err
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	} else if err := this.end(); err != nil {
+		return nil, err
+	}
+
+	// Return sprites
+	return this.sprites, nil
+}
+
+func (this *parser) parse_line(line uint, text string) error {
+	trimmed := strings.TrimSpace(text)
+	switch {
+	case trimmed == "":
+		// Blank lines end the grid
+		if this.current != nil && len(this.current.rows) > 0 {
+			this.current.closed = true
+		}
+	case strings.HasPrefix(trimmed, "#"):
+		// Comment
+	case strings.HasPrefix(trimmed, "["):
+		// Sprite name
+		if strings.HasSuffix(trimmed, "]") == false {
+			return parse_error(line, column_of(text, len(text)), "Missing ']'")
+		} else if name := strings.TrimSpace(trimmed[1 : len(trimmed)-1]); name == "" {
+			return parse_error(line, column_of(text, strings.Index(text, "[")+1), "Missing sprite name")
+		} else if this.names[name] {
+			return parse_error(line, column_of(text, strings.Index(text, name)), fmt.Sprintf("Duplicate sprite name '%v'", name))
+		} else if err := this.end(); err != nil {
+			return err
+		} else {
+			this.names[name] = true
+			this.current = &definition{name: name, line: line}
+		}
+	case legend_line.MatchString(text):
+		// Legend
+		if err := this.parse_legend(line, text); err != nil {
+			return err
+		} else if this.current != nil && len(this.current.rows) > 0 {
+			this.current.closed = true
+		}
+	default:
+		// Pixel row, which starts a new sprite after the previous grid has ended
+		if this.current == nil || (this.current.closed && len(this.current.rows) > 0) {
+			if err := this.end(); err != nil {
+				return err
+			}
+			this.current = &definition{line: line}
+		}
+		pixels := []rune(text)
+		for i, pixel := range pixels {
+			if unicode.IsSpace(pixel) == false {
+				continue
+			} else if i == 1 {
+				// A character followed by whitespace is a legend entry
+				// without the equals sign
+				return parse_error(line, column_of(text, len(text)-len(strings.TrimLeftFunc(text[len(string(pixels[0])):], unicode.IsSpace))), "Missing '=' in legend")
+			} else {
+				return parse_error(line, uint(i+1), "Unexpected whitespace in pixel row")
+			}
+		}
+		this.current.rows = append(this.current.rows, row{line, pixels})
+	}
+	return nil
+}
+
+func (this *parser) parse_legend(line uint, text string) error {
+	// The key and equals sign are matched by legend_line
+	match := legend_line.FindStringSubmatchIndex(text)
+	key, equals := []rune(text[match[2]:match[3]]), match[4]
+
+	// Remove any comment in parentheses, of the form (X = origin)
+	value := text[equals+1:]
+	if start := strings.Index(value, "("); start >= 0 {
+		start += equals + 1
+		if end := strings.LastIndex(text, ")"); end < start {
+			return parse_error(line, column_of(text, len(text)), "Missing ')'")
+		} else if err := this.parse_legend_comment(line, column_of(text, start+1), text[start+1:end]); err != nil {
+			return err
+		} else if strings.TrimSpace(text[end+1:]) != "" {
+			return parse_error(line, column_of(text, end+1), "Unexpected characters after ')'")
+		} else {
+			value = text[equals+1 : start]
+		}
+	}
+
+	// Parse the color
+	offset := equals + 1 + len(value) - len(strings.TrimLeftFunc(value, unicode.IsSpace))
+	if value = strings.TrimSpace(value); value == "" {
+		return parse_error(line, column_of(text, equals+1), "Missing legend color")
+	} else if color, err := color_from_string(value); err != nil {
+		return parse_error(line, column_of(text, offset), err.Error())
+	} else {
+		this.legend[key[0]] = color
+	}
+
+	// Success
+	return nil
+}
+
+func (this *parser) parse_legend_comment(line, column uint, text string) error {
+	// Comments of the form X = origin are checked for the origin
+	// being an uppercase letter, other comments are ignored
+	if parts := strings.SplitN(text, "=", 2); len(parts) != 2 {
+		return nil
+	} else if strings.TrimSpace(parts[1]) != LEGEND_ORIGIN {
+		return nil
+	} else if key := []rune(strings.TrimSpace(parts[0])); len(key) != 1 || unicode.IsUpper(key[0]) == false {
+		return parse_error(line, column, "Origin should be a single uppercase character")
+	}
+	return nil
+}
+
+// end completes the current sprite definition and appends the sprite
+func (this *parser) end() error {
+	def := this.current
+	this.current = nil
+	if def == nil {
+		return nil
+	} else if len(def.rows) == 0 {
+		if def.name != "" {
+			return parse_error(def.line, 1, fmt.Sprintf("Sprite '%v' has no pixels", def.name))
+		}
+		return nil
+	}
+
+	// Name the sprite
+	if def.name == "" {
+		if def.name = this.name; this.unnamed > 0 {
+			def.name = fmt.Sprintf("%v_%v", this.name, this.unnamed)
+		}
+		if this.unnamed++; this.names[def.name] {
+			return parse_error(def.line, 1, fmt.Sprintf("Duplicate sprite name '%v'", def.name))
+		}
+		this.names[def.name] = true
+	}
+
+	// Create the image and set the pixels
+	width := len(def.rows[0].pixels)
+	sprite := &sprite{
+		name:  def.name,
+		image: image.NewNRGBA(image.Rect(0, 0, width, len(def.rows))),
+	}
+	hotspot := false
+	for y, row := range def.rows {
+		if len(row.pixels) != width {
+			// The column is the first missing or extra pixel
+			column := width
+			if len(row.pixels) < width {
+				column = len(row.pixels)
+			}
+			return parse_error(row.line, uint(column+1), fmt.Sprintf("Expected %v pixels in row but found %v", width, len(row.pixels)))
+		}
+		for x, pixel := range row.pixels {
+			color, exists := this.legend[pixel]
+			if exists == false && unicode.IsUpper(pixel) {
+				// Uppercase letter marks the hotspot
+				if color, exists = this.legend[unicode.ToLower(pixel)]; exists == false {
+					return parse_error(row.line, uint(x+1), fmt.Sprintf("Undefined pixel '%c'", pixel))
+				} else if hotspot {
+					return parse_error(row.line, uint(x+1), "Sprite has more than one origin")
+				} else {
+					sprite.hotspot = gopi.Point{float32(x), float32(y)}
+					hotspot = true
+				}
+			} else if exists == false {
+				return parse_error(row.line, uint(x+1), fmt.Sprintf("Undefined pixel '%c'", pixel))
+			}
+			sprite.image.SetNRGBA(x, y, nrgba_from_color(color))
+		}
+	}
+
+	// Append sprite
+	this.sprites = append(this.sprites, sprite)
+
+	// Success
+	return nil
+}
+
+////////////////////////////////////////////////////////////////////////////////
+// STRINGIFY
+
+func (this *ParseError) Error() string {
+	return fmt.Sprintf("Line %v, column %v: %v", this.Line, this.Column, this.Reason)
+}
+
+////////////////////////////////////////////////////////////////////////////////
+// PRIVATE METHODS
+
+func parse_error(line, column uint, reason string) error {
+	return &ParseError{line, column, reason}
+}
+
+// column_of returns the one-based column for a byte offset into a line
+func column_of(text string, offset int) uint {
+	if offset > len(text) {
+		offset = len(text)
+	}
+	return uint(len([]rune(text[:offset])) + 1)
+}
+
+func color_from_string(value string) (gopi.Color, error) {
+	if strings.HasPrefix(value, "#") {
+		hex := value[1:]
+		if len(hex) == 6 {
+			hex = hex + "FF"
+		}
+		if len(hex) != 8 {
+			return gopi.Color{}, fmt.Errorf("Invalid color '%v'", value)
+		} else if v, err := strconv.ParseUint(hex, 16, 32); err != nil {
+			return gopi.Color{}, fmt.Errorf("Invalid color '%v'", value)
+		} else {
+			return gopi.Color{
+				float32(v>>24&0xFF) / 0xFF,
+				float32(v>>16&0xFF) / 0xFF,
+				float32(v>>8&0xFF) / 0xFF,
+				float32(v&0xFF) / 0xFF,
+			}, nil
+		}
+	} else if color, exists := legend_colors[strings.ToLower(value)]; exists {
+		return color, nil
+	} else {
+		return gopi.Color{}, fmt.Errorf("Unknown color '%v'", value)
+	}
+}
@@ -0,0 +1,101 @@
+/*
+  Go Language Raspberry Pi Interface
+  (c) Copyright David Thorpe 2019
+  All Rights Reserved
+
+  Documentation http://djthorpe.github.io/gopi/
+  For Licensing and Usage information, please see LICENSE.md
+*/
+
+package sprites_test
+
+import (
+	"strings"
+	"testing"
+
+	// Frameworks
+	gopi "github.com/djthorpe/gopi"
+	logger "github.com/djthorpe/gopi-graphics/sys/internal/logger"
+	sprites "github.com/djthorpe/gopi-graphics/sys/sprites"
+)
+
+////////////////////////////////////////////////////////////////////////////////
+// TESTS
+
+func Test_Parse_000(t *testing.T) {
+	// Valid sprites, including a row of pixels which includes '='
+	manager := open_manager(t, nil)
+	defer manager.Close()
+	sprites, err := manager.OpenSprites(strings.NewReader(`
+[arrow]
+..Bb
+.bwb
+
+b = black (B = origin)
+w = #FFFFFF80
+. = transparent
+
+[bar]
+==
+= = red
+`))
+	if err != nil {
+		t.Fatal(err)
+	} else if len(sprites) != 2 {
+		t.Fatal("Expected two sprites, got", sprites)
+	}
+	if arrow := sprites[0]; arrow.Name() != "arrow" {
+		t.Error("Unexpected name", arrow.Name())
+	} else if arrow.Size() != (gopi.Size{4, 2}) {
+		t.Error("Unexpected size", arrow.Size())
+	} else if arrow.Hotspot() != (gopi.Point{2, 0}) {
+		t.Error("Unexpected hotspot", arrow.Hotspot())
+	}
+	if bar := sprites[1]; bar.Name() != "bar" {
+		t.Error("Unexpected name", bar.Name())
+	} else if bar.Size() != (gopi.Size{2, 1}) {
+		t.Error("Unexpected size", bar.Size())
+	}
+}
+
+func Test_Parse_001(t *testing.T) {
+	// Errors report the line and column
+	tests := []struct {
+		name         string
+		text         string
+		line, column uint
+	}{
+		{"unknown legend character", "b = black\nbbb\nbxb\n", 3, 2},
+		{"ragged short row", "b = black\nbbb\nbb\n", 3, 3},
+		{"ragged long row", "b = black\nbbb\nbbbb\n", 3, 4},
+		{"missing equals", "b = black\nw white\nbw\n", 2, 3},
+		{"missing color", "b = black\nw = \n", 2, 4},
+		{"unknown color", "b =   blak\n", 1, 7},
+		{"duplicate name", "b = black\n[a]\nb\n\n[b]\nb\n\n[ a ]\nb\n", 8, 3},
+		{"duplicate unnamed", "b = black\n[sprite_1]\nb\n\nb\n\nb\n", 7, 1},
+		{"two origins", "b = black\nBB\n", 2, 2},
+	}
+	manager := open_manager(t, nil)
+	defer manager.Close()
+	for _, test := range tests {
+		_, err := manager.OpenSprites(strings.NewReader(test.text))
+		if err_, ok := err.(*sprites.ParseError); ok == false {
+			t.Errorf("%v: expected ParseError, got %v", test.name, err)
+		} else if err_.Line != test.line || err_.Column != test.column {
+			t.Errorf("%v: expected line %v column %v, got %v", test.name, test.line, test.column, err_)
+		}
+	}
+}
+
+////////////////////////////////////////////////////////////////////////////////
+// PRIVATE METHODS
+
+func open_manager(t *testing.T, gfx gopi.SurfaceManager) sprites.SpriteRegistry {
+	t.Helper()
+	if manager, err := gopi.Open(sprites.SpriteManager{Graphics: gfx}, logger.New(t)); err != nil {
+		t.Fatal(err)
+	} else {
+		return manager.(sprites.SpriteRegistry)
+	}
+	return nil
+}
@@ -0,0 +1,80 @@
+/*
+  Go Language Raspberry Pi Interface
+  (c) Copyright David Thorpe 2019
+  All Rights Reserved
+
+  Documentation http://djthorpe.github.io/gopi/
+  For Licensing and Usage information, please see LICENSE.md
+*/
+
+package sprites
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+
+	// Frameworks
+	gopi "github.com/djthorpe/gopi"
+)
+
+////////////////////////////////////////////////////////////////////////////////
+// TYPES
+
+type sprite struct {
+	name    string
+	hotspot gopi.Point
+	image   *image.NRGBA
+}
+
+////////////////////////////////////////////////////////////////////////////////
+// IMPLEMENTATION
+
+func (this *sprite) Name() string {
+	return this.name
+}
+
+func (this *sprite) Hotspot() gopi.Point {
+	return this.hotspot
+}
+
+func (this *sprite) Type() gopi.SurfaceFlags {
+	return gopi.SURFACE_FLAG_BITMAP | gopi.SURFACE_FLAG_RGBA32
+}
+
+func (this *sprite) Size() gopi.Size {
+	bounds := this.image.Bounds()
+	return gopi.Size{float32(bounds.Dx()), float32(bounds.Dy())}
+}
+
+func (this *sprite) ClearToColor(c gopi.Color) error {
+	return this.FillRectToColor(gopi.ZeroPoint, this.Size(), c)
+}
+
+func (this *sprite) FillRectToColor(origin gopi.Point, size gopi.Size, c gopi.Color) error {
+	rect := image.Rect(int(origin.X), int(origin.Y), int(origin.X)+int(size.W), int(origin.Y)+int(size.H))
+	rect = rect.Intersect(this.image.Bounds())
+	nrgba := nrgba_from_color(c)
+	for y := rect.Min.Y; y < rect.Max.Y; y++ {
+		for x := rect.Min.X; x < rect.Max.X; x++ {
+			this.image.SetNRGBA(x, y, nrgba)
+		}
+	}
+	return nil
+}
+
+////////////////////////////////////////////////////////////////////////////////
+// STRINGIFY
+
+func (this *sprite) String() string {
+	return fmt.Sprintf("<graphics.sprite>{ name=%v size=%v hotspot=%v }", this.name, this.Size(), this.hotspot)
+}
+
+////////////////////////////////////////////////////////////////////////////////
+// PRIVATE METHODS
+
+func nrgba_from_color(c gopi.Color) color.NRGBA {
+	// Returns color 0000 <= v <= FFFF
+	r, g, b, a := c.RGBA()
+	return color.NRGBA{uint8(r >> 8), uint8(g >> 8), uint8(b >> 8), uint8(a >> 8)}
+}
@@ -0,0 +1,83 @@
+/*
+  Go Language Raspberry Pi Interface
+  (c) Copyright David Thorpe 2016-2019
+  All Rights Reserved
+
+  Documentation http://djthorpe.github.io/gopi/
+  For Licensing and Usage information, please see LICENSE.md
+*/
+
+package surface
+
+import (
+	"errors"
+	"image"
+
+	// Frameworks
+	gopi "github.com/djthorpe/gopi"
+)
+
+////////////////////////////////////////////////////////////////////////////////
+// TYPES
+
+// MaskBitmap is implemented by bitmaps created by the surface manager
+// in addition to gopi.Bitmap, in order to draw anti-aliased shapes
+// such as text
+type MaskBitmap interface {
+	gopi.Bitmap
+
+	// Blend a color onto the bitmap through an alpha mask. Each pixel
+	// of the mask at (x,y) is drawn at (origin.X+x,origin.Y+y) and the
+	// mask is clipped to the bitmap bounds
+	FillMaskToColor(origin gopi.Point, mask *image.Alpha, color gopi.Color) error
+}
+
+// PixelBitmap is implemented by bitmaps created by the surface manager
+// in addition to gopi.Bitmap, in order to read and write pixels, which
+// are converted to and from the pixel format of the bitmap
+type PixelBitmap interface {
+	gopi.Bitmap
+
+	// Read a rectangle of pixels, where the bounds of the image
+	// are the intersection of the rectangle and the bitmap
+	ReadPixels(origin gopi.Point, size gopi.Size) (*image.NRGBA, error)
+
+	// Write an image into the bitmap, where the top left of the image
+	// is drawn at the origin and the image is clipped to the bitmap
+	WritePixels(origin gopi.Point, src image.Image) error
+}
+
+////////////////////////////////////////////////////////////////////////////////
+// ERRORS
+
+var (
+	// ErrBitmapInUse is returned when a bitmap is destroyed while it
+	// is drawn by a surface
+	ErrBitmapInUse = errors.New("Bitmap is in use by a surface")
+)
+
+////////////////////////////////////////////////////////////////////////////////
+// REFERENCE COUNTING
+
+// retain increments the number of surfaces which draw the bitmap
+func (this *bitmap) retain() {
+	this.Lock()
+	defer this.Unlock()
+	this.ref++
+}
+
+// release decrements the number of surfaces which draw the bitmap
+func (this *bitmap) release() {
+	this.Lock()
+	defer this.Unlock()
+	if this.ref > 0 {
+		this.ref--
+	}
+}
+
+// in_use returns true if the bitmap is drawn by any surfaces
+func (this *bitmap) in_use() bool {
+	this.Lock()
+	defer this.Unlock()
+	return this.ref > 0
+}
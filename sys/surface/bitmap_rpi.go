@@ -13,6 +13,8 @@ package surface
 
 import (
 	"fmt"
+	"image"
+	"image/color"
 	"unsafe"
 
 	// Frameworks
@@ -58,35 +60,110 @@ func (this *bitmap) ClearToColor(c gopi.Color) error {
 func (this *bitmap) FillRectToColor(origin gopi.Point, size gopi.Size, color gopi.Color) error {
 	this.log.Debug2("<graphics.surfacemanager>FillRectToColor{ origin=%v size=%v color=%v }", origin, size, color)
 
-	// Calculate the intersection between the the rectangle and the surface frame
-	// If width or height is zero there is no intersection to return error
-	frame := rpi.DX_NewRect(0, 0, uint32(this.size.W), uint32(this.size.H))
-	rect := rpi.DX_NewRect(int32(origin.X), int32(origin.Y), uint32(size.W), uint32(size.H))
-	if intersection := rpi.DX_RectIntersection(frame, rect); intersection == nil {
+	// Calculate the intersection between the the rectangle and the bitmap
+	intersection := rect_bounds(origin, size, this.Size())
+	if intersection.Empty() {
 		return nil
-	} else if origin, size := rpi.DX_RectOrigin(intersection), rpi.DX_RectSize(intersection); origin.X == 0 && origin.Y == 0 && size.W == this.size.W && size.H == this.size.H {
+	} else if intersection.Eq(image.Rect(0, 0, int(this.size.W), int(this.size.H))) {
 		// Intersection is the whole image, so use 'ClearToColor'
 		return this.ClearToColor(color)
-	} else if size.W > 0 && size.H > 0 {
-		// Create a strip of data
-		data := make([]byte, 0, size.W*this.bytes_per_pixel)
-		src := color_to_bytes(color, this.image_type)
-		for i := uint32(0); i < size.W; i++ {
-			data = append(data, src...)
+	}
+
+	// Set the pixels in the rows of the intersection
+	data, err := this.read_rows(intersection, true)
+	if err != nil {
+		return err
+	}
+	src := color_to_bytes(color, this.image_type)
+	for y := 0; y < intersection.Dy(); y++ {
+		offset := uint32(y)*this.stride + uint32(intersection.Min.X)*this.bytes_per_pixel
+		for x := 0; x < intersection.Dx(); x++ {
+			offset += uint32(copy(data[offset:], src))
+		}
+	}
+
+	// Write back the rows which have changed
+	return this.write_rows(data, intersection)
+}
+
+func (this *bitmap) FillMaskToColor(origin gopi.Point, mask *image.Alpha, color gopi.Color) error {
+	this.log.Debug2("<graphics.surfacemanager>FillMaskToColor{ origin=%v mask=%v color=%v }", origin, mask.Bounds(), color)
+
+	// Return if the mask does not intersect the bitmap
+	intersection := mask_bounds(origin, mask, this.Size())
+	if intersection.Empty() {
+		return nil
+	}
+
+	// Read the rows of the intersection, as blending depends on the
+	// existing pixels
+	data, err := this.read_rows(intersection, false)
+	if err != nil {
+		return err
+	}
+
+	// Blend each pixel of the mask which intersects the bitmap
+	src := nrgba_from_color(color)
+	for y := intersection.Min.Y; y < intersection.Max.Y; y++ {
+		for x := intersection.Min.X; x < intersection.Max.X; x++ {
+			if coverage := mask.AlphaAt(x-int(origin.X), y-int(origin.Y)).A; coverage != 0 {
+				offset := uint32(y-intersection.Min.Y)*this.stride + uint32(x)*this.bytes_per_pixel
+				c := blend_over(bytes_to_pixel(data[offset:], this.image_type), src, coverage)
+				copy(data[offset:], pixel_to_bytes(c.R, c.G, c.B, c.A, this.image_type))
+			}
 		}
-		// Set the pointer to the strip and move y forward and ptr back for each strip
-		ptr := uintptr(unsafe.Pointer(&data[0]))
-		rect := rpi.DX_NewRect(0, 0, uint32(size.W), 1)
-		for y := uint32(0); y < size.H; y++ {
-			rpi.DX_RectSet(rect, 0, int32(y), uint32(size.W), 1)
-			if err := rpi.DX_ResourceWriteData(this.handle, this.image_type, uint32(len(data)), ptr, rect); err != nil {
-				return err
+	}
+
+	// Write back the rows which have changed
+	return this.write_rows(data, intersection)
+}
+
+func (this *bitmap) ReadPixels(origin gopi.Point, size gopi.Size) (*image.NRGBA, error) {
+	this.log.Debug2("<graphics.surfacemanager>ReadPixels{ origin=%v size=%v }", origin, size)
+
+	// Read the rows of the intersection of the rectangle and the bitmap
+	// and convert the pixels
+	intersection := rect_bounds(origin, size, this.Size())
+	img := image.NewNRGBA(intersection)
+	if intersection.Empty() {
+		return img, nil
+	} else if data, err := this.read_rows(intersection, false); err != nil {
+		return nil, err
+	} else {
+		for y := intersection.Min.Y; y < intersection.Max.Y; y++ {
+			for x := intersection.Min.X; x < intersection.Max.X; x++ {
+				offset := uint32(y-intersection.Min.Y)*this.stride + uint32(x)*this.bytes_per_pixel
+				img.SetNRGBA(x, y, bytes_to_pixel(data[offset:], this.image_type))
 			}
-			// Offset pointer backwards
-			ptr -= uintptr(len(data))
 		}
+		return img, nil
 	}
-	return nil
+}
+
+func (this *bitmap) WritePixels(origin gopi.Point, src image.Image) error {
+	this.log.Debug2("<graphics.surfacemanager>WritePixels{ origin=%v bounds=%v }", origin, src.Bounds())
+
+	// Return if the image does not intersect the bitmap
+	intersection, delta := image_bounds(origin, src, this.Size())
+	if intersection.Empty() {
+		return nil
+	}
+
+	// Set the pixels in the rows of the intersection
+	data, err := this.read_rows(intersection, true)
+	if err != nil {
+		return err
+	}
+	for y := intersection.Min.Y; y < intersection.Max.Y; y++ {
+		for x := intersection.Min.X; x < intersection.Max.X; x++ {
+			offset := uint32(y-intersection.Min.Y)*this.stride + uint32(x)*this.bytes_per_pixel
+			c := nrgba_from_image(src.At(x+delta.X, y+delta.Y))
+			copy(data[offset:], pixel_to_bytes(c.R, c.G, c.B, c.A, this.image_type))
+		}
+	}
+
+	// Write back the rows which have changed
+	return this.write_rows(data, intersection)
 }
 
 ////////////////////////////////////////////////////////////////////////////////
@@ -99,22 +176,76 @@ func (this *bitmap) String() string {
 ////////////////////////////////////////////////////////////////////////////////
 // PRIVATE METHODS
 
+// read_rows returns the pixel data for the rows of a rectangle, where
+// the first row of data is the top of the rectangle. Resource data is
+// written in whole rows, so the rows are read from the resource unless
+// every pixel of the rows is replaced
+func (this *bitmap) read_rows(r image.Rectangle, replace bool) ([]byte, error) {
+	data := make([]byte, this.stride*uint32(r.Dy()))
+	if replace && r.Min.X == 0 && r.Max.X == int(this.size.W) {
+		return data, nil
+	}
+	rect := rpi.DX_NewRect(0, int32(r.Min.Y), uint32(this.size.W), uint32(r.Dy()))
+	if err := rpi.DX_ResourceReadData(this.handle, rect, this.rows_ptr(data, r.Min.Y), this.stride); err != nil {
+		return nil, err
+	} else {
+		return data, nil
+	}
+}
+
+// write_rows writes the pixel data returned by read_rows for the rows
+// of a rectangle
+func (this *bitmap) write_rows(data []byte, r image.Rectangle) error {
+	rect := rpi.DX_NewRect(0, int32(r.Min.Y), uint32(this.size.W), uint32(r.Dy()))
+	return rpi.DX_ResourceWriteData(this.handle, this.image_type, this.stride, this.rows_ptr(data, r.Min.Y), rect)
+}
+
+// rows_ptr returns the data pointer for rows starting at y, which is
+// offset back by the rows above, as the pointer is offset forward by
+// the row of the rectangle when data is read or written
+func (this *bitmap) rows_ptr(data []byte, y int) uintptr {
+	return uintptr(unsafe.Pointer(&data[0])) - uintptr(y)*uintptr(this.stride)
+}
+
 func color_to_bytes(c gopi.Color, t rpi.DX_ImageType) []byte {
 	// Returns color 0000 <= v <= FFFF
 	r, g, b, a := c.RGBA()
 	// Convert to []byte
+	return pixel_to_bytes(uint8(r>>8), uint8(g>>8), uint8(b>>8), uint8(a>>8), t)
+}
+
+func pixel_to_bytes(r, g, b, a uint8, t rpi.DX_ImageType) []byte {
 	switch t {
 	case rpi.DX_IMAGE_TYPE_RGB888:
-		return []byte{byte(r >> 8), byte(g >> 8), byte(b >> 8)}
+		return []byte{r, g, b}
 	case rpi.DX_IMAGE_TYPE_RGB565:
-		r := uint16(r>>(8+3)) << (5 + 6)
-		g := uint16(g>>(8+2)) << 5
-		b := uint16(b >> (8 + 3))
+		r := uint16(r>>3) << (5 + 6)
+		g := uint16(g>>2) << 5
+		b := uint16(b >> 3)
 		v := r | g | b
 		return []byte{byte(v), byte(v >> 8)}
 	case rpi.DX_IMAGE_TYPE_RGBA32:
-		return []byte{byte(r >> 8), byte(g >> 8), byte(b >> 8), byte(a >> 8)}
+		return []byte{r, g, b, a}
 	default:
 		return nil
 	}
 }
+
+// bytes_to_pixel returns the color of a pixel, where the alpha value is
+// fully opaque if the image type has no alpha channel
+func bytes_to_pixel(data []byte, t rpi.DX_ImageType) color.NRGBA {
+	switch t {
+	case rpi.DX_IMAGE_TYPE_RGB888:
+		return color.NRGBA{data[0], data[1], data[2], 0xFF}
+	case rpi.DX_IMAGE_TYPE_RGB565:
+		v := uint16(data[0]) | uint16(data[1])<<8
+		r := uint8(v>>(5+6)) & 0x1F
+		g := uint8(v>>5) & 0x3F
+		b := uint8(v) & 0x1F
+		return color.NRGBA{r<<3 | r>>2, g<<2 | g>>4, b<<3 | b>>2, 0xFF}
+	case rpi.DX_IMAGE_TYPE_RGBA32:
+		return color.NRGBA{data[0], data[1], data[2], data[3]}
+	default:
+		return color.NRGBA{}
+	}
+}
@@ -0,0 +1,168 @@
+// +build rpi
+
+/*
+  Go Language Raspberry Pi Interface
+  (c) Copyright David Thorpe 2016-2019
+  All Rights Reserved
+
+  Documentation http://djthorpe.github.io/gopi/
+  For Licensing and Usage information, please see LICENSE.md
+*/
+
+package surface_test
+
+import (
+	"image"
+	"image/color"
+	"testing"
+
+	// Frameworks
+	gopi "github.com/djthorpe/gopi"
+	display "github.com/djthorpe/gopi-graphics/sys/display"
+	logger "github.com/djthorpe/gopi-graphics/sys/internal/logger"
+	surface "github.com/djthorpe/gopi-graphics/sys/surface"
+)
+
+////////////////////////////////////////////////////////////////////////////////
+// GLOBAL VARIABLES
+
+var (
+	// Colors which are the same in every pixel format
+	black = color.NRGBA{0x00, 0x00, 0x00, 0xFF}
+	blue  = color.NRGBA{0x00, 0x00, 0xFF, 0xFF}
+	red   = color.NRGBA{0xFF, 0x00, 0x00, 0xFF}
+	white = color.NRGBA{0xFF, 0xFF, 0xFF, 0xFF}
+)
+
+////////////////////////////////////////////////////////////////////////////////
+// TESTS
+
+func Test_Pixels_000(t *testing.T) {
+	// Write pixels into part of each row, and read them back
+	d, gfx := open_rpi_manager(t)
+	defer d.Close()
+	defer gfx.Close()
+	for _, config := range []gopi.SurfaceFlags{gopi.SURFACE_FLAG_RGBA32, gopi.SURFACE_FLAG_RGB888, gopi.SURFACE_FLAG_RGB565} {
+		bitmap := create_rpi_bitmap(t, gfx, config, blue)
+		src := image.NewNRGBA(image.Rect(0, 0, 4, 3))
+		for y := 0; y < 3; y++ {
+			for x := 0; x < 4; x++ {
+				if (x+y)%2 == 0 {
+					src.SetNRGBA(x, y, white)
+				} else {
+					src.SetNRGBA(x, y, red)
+				}
+			}
+		}
+		if err := bitmap.WritePixels(gopi.Point{3, 2}, src); err != nil {
+			t.Fatal(err)
+		}
+		expect_rpi_pixels(t, bitmap, func(x, y int) color.NRGBA {
+			if image.Pt(x, y).In(image.Rect(3, 2, 7, 5)) {
+				return src.NRGBAAt(x-3, y-2)
+			} else {
+				return blue
+			}
+		})
+		gfx.DestroyBitmap(bitmap)
+	}
+}
+
+func Test_Pixels_001(t *testing.T) {
+	// Write whole rows, which are not read back first, and fill
+	// part of some rows
+	d, gfx := open_rpi_manager(t)
+	defer d.Close()
+	defer gfx.Close()
+	for _, config := range []gopi.SurfaceFlags{gopi.SURFACE_FLAG_RGBA32, gopi.SURFACE_FLAG_RGB888, gopi.SURFACE_FLAG_RGB565} {
+		bitmap := create_rpi_bitmap(t, gfx, config, blue)
+		src := image.NewNRGBA(image.Rect(0, 0, 30, 2))
+		for i := 0; i < len(src.Pix); i += 4 {
+			copy(src.Pix[i:], []byte{white.R, white.G, white.B, white.A})
+		}
+		if err := bitmap.WritePixels(gopi.Point{-5, 6}, src); err != nil {
+			t.Fatal(err)
+		} else if err := bitmap.FillRectToColor(gopi.Point{10, 1}, gopi.Size{5, 2}, gopi.Color{1, 0, 0, 1}); err != nil {
+			t.Fatal(err)
+		} else if err := bitmap.FillRectToColor(gopi.Point{18, 7}, gopi.Size{5, 5}, gopi.Color{0, 0, 0, 1}); err != nil {
+			t.Fatal(err)
+		}
+		expect_rpi_pixels(t, bitmap, func(x, y int) color.NRGBA {
+			switch {
+			case image.Pt(x, y).In(image.Rect(10, 1, 15, 3)):
+				return red
+			case image.Pt(x, y).In(image.Rect(18, 7, 20, 10)):
+				return black
+			case y == 6 || y == 7:
+				return white
+			default:
+				return blue
+			}
+		})
+		gfx.DestroyBitmap(bitmap)
+	}
+}
+
+////////////////////////////////////////////////////////////////////////////////
+// PRIVATE METHODS
+
+func open_rpi_manager(t *testing.T) (gopi.Driver, gopi.SurfaceManager) {
+	t.Helper()
+	if d, err := gopi.Open(display.Display{Display: 0}, logger.New(t)); err != nil {
+		t.Fatal(err)
+	} else if gfx, err := gopi.Open(surface.SurfaceManager{Display: d.(gopi.Display)}, logger.New(t)); err != nil {
+		d.Close()
+		t.Fatal(err)
+	} else {
+		return d, gfx.(gopi.SurfaceManager)
+	}
+	return nil, nil
+}
+
+// create_rpi_bitmap creates a 20x10 bitmap cleared to a color
+func create_rpi_bitmap(t *testing.T, gfx gopi.SurfaceManager, config gopi.SurfaceFlags, c color.NRGBA) surface.PixelBitmap {
+	t.Helper()
+	if bitmap, err := gfx.CreateBitmap(gopi.SURFACE_FLAG_BITMAP|config, gopi.Size{20, 10}); err != nil {
+		t.Fatal(err)
+	} else if err := bitmap.ClearToColor(gopi.Color{float32(c.R) / 0xFF, float32(c.G) / 0xFF, float32(c.B) / 0xFF, float32(c.A) / 0xFF}); err != nil {
+		t.Fatal(err)
+	} else {
+		return bitmap.(surface.PixelBitmap)
+	}
+	return nil
+}
+
+// expect_rpi_pixels reads the pixels of a bitmap and compares them
+func expect_rpi_pixels(t *testing.T, bitmap surface.PixelBitmap, expected func(x, y int) color.NRGBA) {
+	t.Helper()
+	img, err := bitmap.ReadPixels(gopi.ZeroPoint, bitmap.Size())
+	if err != nil {
+		t.Fatal(err)
+	} else if img.Bounds() != image.Rect(0, 0, 20, 10) {
+		t.Fatal("Unexpected bounds", img.Bounds())
+	}
+	for y := 0; y < 10; y++ {
+		for x := 0; x < 20; x++ {
+			if pixel := img.NRGBAAt(x, y); pixel != expected(x, y) {
+				t.Errorf("%v: pixel at %v,%v expected %v, got %v", bitmap.Type(), x, y, expected(x, y), pixel)
+				return
+			}
+		}
+	}
+
+	// Reading part of the bitmap returns the same pixels
+	if part, err := bitmap.ReadPixels(gopi.Point{2, 3}, gopi.Size{10, 4}); err != nil {
+		t.Fatal(err)
+	} else if part.Bounds() != image.Rect(2, 3, 12, 7) {
+		t.Error("Unexpected bounds", part.Bounds())
+	} else {
+		for y := 3; y < 7; y++ {
+			for x := 2; x < 12; x++ {
+				if part.NRGBAAt(x, y) != img.NRGBAAt(x, y) {
+					t.Errorf("%v: pixel at %v,%v differs when reading part of the bitmap", bitmap.Type(), x, y)
+					return
+				}
+			}
+		}
+	}
+}
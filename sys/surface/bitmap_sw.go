@@ -0,0 +1,204 @@
+// +build !rpi
+
+/*
+  Go Language Raspberry Pi Interface
+  (c) Copyright David Thorpe 2016-2019
+  All Rights Reserved
+
+  Documentation http://djthorpe.github.io/gopi/
+  For Licensing and Usage information, please see LICENSE.md
+*/
+
+package surface
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+
+	// Frameworks
+	gopi "github.com/djthorpe/gopi"
+)
+
+////////////////////////////////////////////////////////////////////////////////
+// IMPLEMENTATION
+
+func (this *bitmap) Type() gopi.SurfaceFlags {
+	return this.flags.Config()
+}
+
+func (this *bitmap) Size() gopi.Size {
+	return gopi.Size{float32(this.size.W), float32(this.size.H)}
+}
+
+func (this *bitmap) ClearToColor(c gopi.Color) error {
+	this.log.Debug2("<graphics.surfacemanager>ClearToColor{ color=%v }", c)
+	return this.FillRectToColor(gopi.ZeroPoint, this.Size(), c)
+}
+
+func (this *bitmap) FillRectToColor(origin gopi.Point, size gopi.Size, color gopi.Color) error {
+	this.log.Debug2("<graphics.surfacemanager>FillRectToColor{ origin=%v size=%v color=%v }", origin, size, color)
+
+	this.Lock()
+	defer this.Unlock()
+	if this.data == nil {
+		return gopi.ErrOutOfOrder
+	}
+
+	// Calculate the intersection between the the rectangle and the bitmap frame
+	// If there is no intersection then return
+	frame := image.Rect(0, 0, int(this.size.W), int(this.size.H))
+	rect := image.Rect(int(origin.X), int(origin.Y), int(origin.X)+int(size.W), int(origin.Y)+int(size.H))
+	intersection := frame.Intersect(rect)
+	if intersection.Empty() {
+		return nil
+	}
+
+	// Fill the first row of the intersection then copy it into the other rows
+	src := color_to_bytes(color, this.flags)
+	row := this.offset(uint32(intersection.Min.X), uint32(intersection.Min.Y))
+	width := uint32(intersection.Dx()) * this.bytes_per_pixel
+	for i := uint32(0); i < width; i += this.bytes_per_pixel {
+		copy(this.data[row+i:], src)
+	}
+	for y := 1; y < intersection.Dy(); y++ {
+		offset := row + uint32(y)*this.stride
+		copy(this.data[offset:offset+width], this.data[row:row+width])
+	}
+
+	// Return success
+	return nil
+}
+
+func (this *bitmap) FillMaskToColor(origin gopi.Point, mask *image.Alpha, color gopi.Color) error {
+	this.log.Debug2("<graphics.surfacemanager>FillMaskToColor{ origin=%v mask=%v color=%v }", origin, mask.Bounds(), color)
+
+	this.Lock()
+	defer this.Unlock()
+	if this.data == nil {
+		return gopi.ErrOutOfOrder
+	}
+
+	// Blend each pixel of the mask which intersects the bitmap
+	src := nrgba_from_color(color)
+	intersection := mask_bounds(origin, mask, this.Size())
+	for y := intersection.Min.Y; y < intersection.Max.Y; y++ {
+		for x := intersection.Min.X; x < intersection.Max.X; x++ {
+			if coverage := mask.AlphaAt(x-int(origin.X), y-int(origin.Y)).A; coverage != 0 {
+				c := blend_over(this.at(uint32(x), uint32(y)), src, coverage)
+				copy(this.data[this.offset(uint32(x), uint32(y)):], pixel_to_bytes(c.R, c.G, c.B, c.A, this.flags))
+			}
+		}
+	}
+
+	// Return success
+	return nil
+}
+
+func (this *bitmap) ReadPixels(origin gopi.Point, size gopi.Size) (*image.NRGBA, error) {
+	this.log.Debug2("<graphics.surfacemanager>ReadPixels{ origin=%v size=%v }", origin, size)
+
+	this.Lock()
+	defer this.Unlock()
+	if this.data == nil {
+		return nil, gopi.ErrOutOfOrder
+	}
+
+	// Convert the intersection of the rectangle and the bitmap
+	intersection := rect_bounds(origin, size, this.Size())
+	img := image.NewNRGBA(intersection)
+	for y := intersection.Min.Y; y < intersection.Max.Y; y++ {
+		for x := intersection.Min.X; x < intersection.Max.X; x++ {
+			img.SetNRGBA(x, y, this.at(uint32(x), uint32(y)))
+		}
+	}
+
+	// Return the image
+	return img, nil
+}
+
+func (this *bitmap) WritePixels(origin gopi.Point, src image.Image) error {
+	this.log.Debug2("<graphics.surfacemanager>WritePixels{ origin=%v bounds=%v }", origin, src.Bounds())
+
+	this.Lock()
+	defer this.Unlock()
+	if this.data == nil {
+		return gopi.ErrOutOfOrder
+	}
+
+	// Convert each pixel of the image which intersects the bitmap
+	intersection, delta := image_bounds(origin, src, this.Size())
+	for y := intersection.Min.Y; y < intersection.Max.Y; y++ {
+		for x := intersection.Min.X; x < intersection.Max.X; x++ {
+			c := nrgba_from_image(src.At(x+delta.X, y+delta.Y))
+			copy(this.data[this.offset(uint32(x), uint32(y)):], pixel_to_bytes(c.R, c.G, c.B, c.A, this.flags))
+		}
+	}
+
+	// Return success
+	return nil
+}
+
+////////////////////////////////////////////////////////////////////////////////
+// STRINGIFY
+
+func (this *bitmap) String() string {
+	return fmt.Sprintf("<graphics.bitmap>{ type=%v size=%v stride=%v }", this.flags.ConfigString(), this.size, this.stride)
+}
+
+////////////////////////////////////////////////////////////////////////////////
+// PRIVATE METHODS
+
+// offset returns the offset of a pixel in the data
+func (this *bitmap) offset(x, y uint32) uint32 {
+	return y*this.stride + x*this.bytes_per_pixel
+}
+
+// at returns the color of a pixel, where the alpha value is fully
+// opaque if the bitmap has no alpha channel
+func (this *bitmap) at(x, y uint32) color.NRGBA {
+	data := this.data[this.offset(x, y):]
+	switch this.flags.Config() {
+	case gopi.SURFACE_FLAG_RGB888:
+		return color.NRGBA{data[0], data[1], data[2], 0xFF}
+	case gopi.SURFACE_FLAG_RGB565:
+		v := uint16(data[0]) | uint16(data[1])<<8
+		r := uint8(v>>(5+6)) & 0x1F
+		g := uint8(v>>5) & 0x3F
+		b := uint8(v) & 0x1F
+		return color.NRGBA{r<<3 | r>>2, g<<2 | g>>4, b<<3 | b>>2, 0xFF}
+	case gopi.SURFACE_FLAG_RGBA32:
+		return color.NRGBA{data[0], data[1], data[2], data[3]}
+	default:
+		return color.NRGBA{}
+	}
+}
+
+// set sets the color of a pixel from an opaque color
+func (this *bitmap) set(x, y uint32, c color.RGBA) {
+	copy(this.data[this.offset(x, y):], pixel_to_bytes(c.R, c.G, c.B, c.A, this.flags))
+}
+
+func color_to_bytes(c gopi.Color, flags gopi.SurfaceFlags) []byte {
+	// Returns color 0000 <= v <= FFFF
+	r, g, b, a := c.RGBA()
+	// Convert to []byte
+	return pixel_to_bytes(uint8(r>>8), uint8(g>>8), uint8(b>>8), uint8(a>>8), flags)
+}
+
+func pixel_to_bytes(r, g, b, a uint8, flags gopi.SurfaceFlags) []byte {
+	switch flags.Config() {
+	case gopi.SURFACE_FLAG_RGB888:
+		return []byte{r, g, b}
+	case gopi.SURFACE_FLAG_RGB565:
+		r := uint16(r>>3) << (5 + 6)
+		g := uint16(g>>2) << 5
+		b := uint16(b >> 3)
+		v := r | g | b
+		return []byte{byte(v), byte(v >> 8)}
+	case gopi.SURFACE_FLAG_RGBA32:
+		return []byte{r, g, b, a}
+	default:
+		return nil
+	}
+}
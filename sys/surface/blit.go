@@ -0,0 +1,169 @@
+/*
+  Go Language Raspberry Pi Interface
+  (c) Copyright David Thorpe 2016-2019
+  All Rights Reserved
+
+  Documentation http://djthorpe.github.io/gopi/
+  For Licensing and Usage information, please see LICENSE.md
+*/
+
+package surface
+
+import (
+	"fmt"
+	"image"
+
+	// Frameworks
+	gopi "github.com/djthorpe/gopi"
+)
+
+////////////////////////////////////////////////////////////////////////////////
+// TYPES
+
+// BitmapBlitter is implemented by the surface manager in addition to
+// gopi.SurfaceManager, in order to copy pixels between bitmaps
+type BitmapBlitter interface {
+	// Copy a rectangle of the source bitmap into the destination bitmap,
+	// where the top left of the rectangle is drawn at the origin. An empty
+	// rectangle copies the whole source bitmap. The rectangle is clipped to
+	// the source bitmap and the destination is clipped to the destination
+	// bitmap. Pixels are converted when the pixel formats differ, and the
+	// source and destination can be the same bitmap. When the mode has an
+	// opacity less than 1.0 and the destination has no alpha channel, a
+	// copy is blended with the destination pixels
+	Blit(dst, src gopi.Bitmap, rect image.Rectangle, origin gopi.Point, mode BlitMode) error
+}
+
+// BlitMode determines how source pixels are combined with destination
+// pixels, and the constant opacity of the source between 0.0 and 1.0
+type BlitMode uint32
+
+////////////////////////////////////////////////////////////////////////////////
+// CONSTANTS
+
+const (
+	// Replace destination pixels with source pixels
+	BLIT_MODE_COPY BlitMode = 0x0000
+
+	// Blend source pixels over destination pixels using source alpha
+	BLIT_MODE_SRC_OVER BlitMode = 0x0001
+
+	BLIT_MODE_MASK BlitMode = 0x00FF
+
+	// Opacity of the source, which is set with BlitModeWithOpacity. The
+	// bits hold the transparency so that a mode without them is opaque
+	BLIT_MODE_OPACITY_MASK  BlitMode = 0xFF00
+	BLIT_MODE_OPACITY_SHIFT          = 8
+)
+
+////////////////////////////////////////////////////////////////////////////////
+// BLIT
+
+func (this *manager) Blit(dst, src gopi.Bitmap, rect image.Rectangle, origin gopi.Point, mode BlitMode) error {
+	this.log.Debug2("<graphics.surfacemanager>Blit{ dst=%v src=%v rect=%v origin=%v mode=%v }", dst, src, rect, origin, mode)
+
+	dst_, ok := dst.(PixelBitmap)
+	if ok == false {
+		return gopi.ErrBadParameter
+	}
+	src_, ok := src.(PixelBitmap)
+	if ok == false {
+		return gopi.ErrBadParameter
+	}
+	if rect.Empty() {
+		size := src.Size()
+		rect = image.Rect(0, 0, int(size.W), int(size.H))
+	}
+
+	// Read the intersection of the rectangle and the source bitmap, and
+	// move the origin by the amount the rectangle was clipped
+	pixels, err := src_.ReadPixels(gopi.Point{float32(rect.Min.X), float32(rect.Min.Y)}, gopi.Size{float32(rect.Dx()), float32(rect.Dy())})
+	if err != nil {
+		return err
+	} else if pixels.Rect.Empty() {
+		return nil
+	}
+	delta := pixels.Rect.Min.Sub(rect.Min)
+	origin = gopi.Point{origin.X + float32(delta.X), origin.Y + float32(delta.Y)}
+
+	// Copy the pixels, or blend them with the destination pixels
+	coverage := mode.coverage()
+	switch mode & BLIT_MODE_MASK {
+	case BLIT_MODE_COPY:
+		if coverage == 0xFF {
+			return dst_.WritePixels(origin, pixels)
+		} else if dst.Type() == gopi.SURFACE_FLAG_RGBA32 {
+			for i := 3; i < len(pixels.Pix); i += 4 {
+				pixels.Pix[i] = uint8(uint32(pixels.Pix[i]) * uint32(coverage) / 0xFF)
+			}
+			return dst_.WritePixels(origin, pixels)
+		} else {
+			// Without alpha in the destination the opacity would be lost,
+			// so blend the source colors, which are copied without their
+			// alpha, with the destination
+			for i := 3; i < len(pixels.Pix); i += 4 {
+				pixels.Pix[i] = 0xFF
+			}
+			return blit_over(dst_, pixels, origin, coverage)
+		}
+	case BLIT_MODE_SRC_OVER:
+		return blit_over(dst_, pixels, origin, coverage)
+	default:
+		return gopi.ErrBadParameter
+	}
+}
+
+// blit_over blends pixels over the destination pixels at an origin
+func blit_over(dst PixelBitmap, pixels *image.NRGBA, origin gopi.Point, coverage uint8) error {
+	if target, err := dst.ReadPixels(origin, gopi.Size{float32(pixels.Rect.Dx()), float32(pixels.Rect.Dy())}); err != nil {
+		return err
+	} else if target.Rect.Empty() {
+		return nil
+	} else {
+		delta := pixels.Rect.Min.Sub(image.Pt(int(origin.X), int(origin.Y)))
+		for y := target.Rect.Min.Y; y < target.Rect.Max.Y; y++ {
+			for x := target.Rect.Min.X; x < target.Rect.Max.X; x++ {
+				target.SetNRGBA(x, y, blend_over(target.NRGBAAt(x, y), pixels.NRGBAAt(x+delta.X, y+delta.Y), coverage))
+			}
+		}
+		return dst.WritePixels(gopi.Point{float32(target.Rect.Min.X), float32(target.Rect.Min.Y)}, target)
+	}
+}
+
+////////////////////////////////////////////////////////////////////////////////
+// BLIT MODE
+
+// BlitModeWithOpacity returns a mode with a constant opacity between
+// 0.0 and 1.0
+func BlitModeWithOpacity(mode BlitMode, opacity float32) BlitMode {
+	if opacity < 0 {
+		opacity = 0
+	} else if opacity > 1 {
+		opacity = 1
+	}
+	// Opaque is stored as zero so that modes are opaque by default
+	value := BlitMode(0xFF-uint8(opacity*0xFF+0.5)) << BLIT_MODE_OPACITY_SHIFT
+	return mode&^BLIT_MODE_OPACITY_MASK | value
+}
+
+// Opacity returns the constant opacity of the source between 0.0 and 1.0,
+// where 1.0 is fully opaque
+func (m BlitMode) Opacity() float32 {
+	return float32(m.coverage()) / 0xFF
+}
+
+// coverage returns the constant opacity of the source between 0 and 255
+func (m BlitMode) coverage() uint8 {
+	return 0xFF - uint8((m&BLIT_MODE_OPACITY_MASK)>>BLIT_MODE_OPACITY_SHIFT)
+}
+
+func (m BlitMode) String() string {
+	switch m & BLIT_MODE_MASK {
+	case BLIT_MODE_COPY:
+		return fmt.Sprintf("BLIT_MODE_COPY<opacity=%.2f>", m.Opacity())
+	case BLIT_MODE_SRC_OVER:
+		return fmt.Sprintf("BLIT_MODE_SRC_OVER<opacity=%.2f>", m.Opacity())
+	default:
+		return "[?? Invalid BlitMode value]"
+	}
+}
@@ -0,0 +1,171 @@
+/*
+  Go Language Raspberry Pi Interface
+  (c) Copyright David Thorpe 2016-2019
+  All Rights Reserved
+
+  Documentation http://djthorpe.github.io/gopi/
+  For Licensing and Usage information, please see LICENSE.md
+*/
+
+package surface
+
+import (
+	"fmt"
+	"image/color"
+
+	// Frameworks
+	gopi "github.com/djthorpe/gopi"
+)
+
+////////////////////////////////////////////////////////////////////////////////
+// TYPES
+
+// SurfaceChromaKeyer is implemented by the surface manager in addition to
+// gopi.SurfaceManager, in order to make pixels of a key color transparent,
+// for bitmaps without an alpha channel
+type SurfaceChromaKeyer interface {
+	// Create a surface with a bitmap within an update, where pixels which
+	// match the key are transparent
+	CreateSurfaceWithChromaKey(bitmap gopi.Bitmap, flags gopi.SurfaceFlags, opacity float32, layer uint16, origin gopi.Point, size gopi.Size, key ChromaKey) (gopi.Surface, error)
+
+	// Set the key for a surface within an update, where a key with mode
+	// CHROMA_KEY_NONE draws every pixel. On the Raspberry Pi the key of an
+	// element cannot be changed, so the element is replaced, which is
+	// slower than other changes to a surface and should not be made on
+	// every frame
+	SetChromaKey(s gopi.Surface, key ChromaKey) error
+
+	// Return the key for a surface
+	ChromaKeyForSurface(s gopi.Surface) ChromaKey
+}
+
+// ChromaKey makes a pixel transparent when every component is between the
+// lower and upper values inclusive. The components are red, green and
+// blue in RGB mode, or luma, blue difference and red difference in YUV mode.
+// YUV mode returns gopi.ErrNotImplemented on the Raspberry Pi
+type ChromaKey struct {
+	Mode  ChromaKeyMode
+	Lower [3]uint8
+	Upper [3]uint8
+}
+
+// ChromaKeyMode determines the color space in which pixels are compared
+// with the key
+type ChromaKeyMode uint
+
+////////////////////////////////////////////////////////////////////////////////
+// CONSTANTS
+
+const (
+	CHROMA_KEY_NONE ChromaKeyMode = iota
+	CHROMA_KEY_RGB
+	CHROMA_KEY_YUV
+)
+
+////////////////////////////////////////////////////////////////////////////////
+// PUBLIC METHODS
+
+// ChromaKeyForColor returns an RGB key for a color, where the tolerance is
+// the difference allowed in each component, so that the color still matches
+// in pixel formats with fewer bits per component
+func ChromaKeyForColor(c gopi.Color, tolerance uint8) ChromaKey {
+	r, g, b, _ := c.RGBA()
+	key := ChromaKey{Mode: CHROMA_KEY_RGB}
+	for i, v := range []uint32{r >> 8, g >> 8, b >> 8} {
+		key.Lower[i] = uint8(v) - min_uint8(uint8(v), tolerance)
+		key.Upper[i] = uint8(v) + min_uint8(0xFF-uint8(v), tolerance)
+	}
+	return key
+}
+
+////////////////////////////////////////////////////////////////////////////////
+// CHROMA KEY
+
+func (this *manager) CreateSurfaceWithChromaKey(bitmap gopi.Bitmap, flags gopi.SurfaceFlags, opacity float32, layer uint16, origin gopi.Point, size gopi.Size, key ChromaKey) (gopi.Surface, error) {
+	if key.is_valid() == false {
+		return nil, gopi.ErrBadParameter
+	} else if s, err := this.CreateSurfaceWithBitmap(bitmap, flags, opacity, layer, origin, size); err != nil {
+		return nil, err
+	} else if err := this.set_chroma_key(s.(*surface), key); err != nil {
+		this.DestroySurface(s)
+		return nil, err
+	} else {
+		return s, nil
+	}
+}
+
+func (this *manager) ChromaKeyForSurface(s gopi.Surface) ChromaKey {
+	if surface_, ok := s.(*surface); ok == false {
+		return ChromaKey{}
+	} else {
+		return surface_.key
+	}
+}
+
+////////////////////////////////////////////////////////////////////////////////
+// STRINGIFY
+
+func (k ChromaKey) String() string {
+	if k.Mode == CHROMA_KEY_NONE {
+		return fmt.Sprint(k.Mode)
+	} else {
+		return fmt.Sprintf("%v<lower=%v upper=%v>", k.Mode, k.Lower, k.Upper)
+	}
+}
+
+func (m ChromaKeyMode) String() string {
+	switch m {
+	case CHROMA_KEY_NONE:
+		return "CHROMA_KEY_NONE"
+	case CHROMA_KEY_RGB:
+		return "CHROMA_KEY_RGB"
+	case CHROMA_KEY_YUV:
+		return "CHROMA_KEY_YUV"
+	default:
+		return "[?? Invalid ChromaKeyMode value]"
+	}
+}
+
+////////////////////////////////////////////////////////////////////////////////
+// PRIVATE METHODS
+
+// is_valid returns true if the mode is valid and the lower value of
+// each component is not greater than the upper value
+func (k ChromaKey) is_valid() bool {
+	if k.Mode > CHROMA_KEY_YUV {
+		return false
+	}
+	for i := range k.Lower {
+		if k.Lower[i] > k.Upper[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// matches returns true if a pixel is transparent
+func (k ChromaKey) matches(c color.NRGBA) bool {
+	var v [3]uint8
+	switch k.Mode {
+	case CHROMA_KEY_RGB:
+		v = [3]uint8{c.R, c.G, c.B}
+	case CHROMA_KEY_YUV:
+		v[0], v[1], v[2] = color.RGBToYCbCr(c.R, c.G, c.B)
+	default:
+		return false
+	}
+	for i := range v {
+		if v[i] < k.Lower[i] || v[i] > k.Upper[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func min_uint8(a, b uint8) uint8 {
+	if a < b {
+		return a
+	} else {
+		return b
+	}
+}
@@ -0,0 +1,190 @@
+/*
+  Go Language Raspberry Pi Interface
+  (c) Copyright David Thorpe 2016-2019
+  All Rights Reserved
+
+  Documentation http://djthorpe.github.io/gopi/
+  For Licensing and Usage information, please see LICENSE.md
+*/
+
+package surface
+
+import (
+	"sync"
+	"time"
+
+	// Frameworks
+	gopi "github.com/djthorpe/gopi"
+)
+
+////////////////////////////////////////////////////////////////////////////////
+// TYPES
+
+// SurfaceFader is implemented by the surface manager in addition to
+// gopi.SurfaceManager, in order to fade surfaces. The opacity of every
+// surface which is fading is changed once for each refresh of the display
+// in the same update as frame callbacks
+type SurfaceFader interface {
+	// Fade the opacity of a surface from one value to another over a
+	// duration, where the easing function determines the rate of change
+	// and nil is linear. A fade in progress for the surface is replaced.
+	// The channel is closed when the fade is complete or cancelled
+	Fade(s gopi.Surface, from, to float32, duration time.Duration, easing Easing) (<-chan struct{}, error)
+
+	// Cancel the fade for a surface, which keeps the current opacity
+	CancelFade(s gopi.Surface) error
+}
+
+// Easing returns the progress of an animation between 0.0 and 1.0 for
+// the fraction of the duration which has elapsed between 0.0 and 1.0
+type Easing func(t float64) float64
+
+// fades are the fades in progress
+type fades struct {
+	fades map[*surface]*fade
+	sync.Mutex
+}
+
+type fade struct {
+	from, to float32
+	duration time.Duration
+	easing   Easing
+	start    time.Time
+	done     chan struct{}
+}
+
+////////////////////////////////////////////////////////////////////////////////
+// EASING
+
+// EaseLinear changes at a constant rate
+func EaseLinear(t float64) float64 {
+	return t
+}
+
+// EaseIn starts slowly and accelerates
+func EaseIn(t float64) float64 {
+	return t * t
+}
+
+// EaseOut starts quickly and decelerates
+func EaseOut(t float64) float64 {
+	return t * (2 - t)
+}
+
+// EaseInOut accelerates and then decelerates
+func EaseInOut(t float64) float64 {
+	if t < 0.5 {
+		return 2 * t * t
+	} else {
+		return -1 + (4-2*t)*t
+	}
+}
+
+////////////////////////////////////////////////////////////////////////////////
+// FADE
+
+func (this *manager) Fade(s gopi.Surface, from, to float32, duration time.Duration, easing Easing) (<-chan struct{}, error) {
+	this.log.Debug2("<graphics.surfacemanager>Fade{ surface=%v from=%v to=%v duration=%v }", s, from, to, duration)
+
+	surface_, ok := s.(*surface)
+	if ok == false {
+		return nil, gopi.ErrBadParameter
+	} else if from < 0.0 || from > 1.0 || to < 0.0 || to > 1.0 {
+		return nil, gopi.ErrBadParameter
+	} else if duration < 0 {
+		return nil, gopi.ErrBadParameter
+	} else if easing == nil {
+		easing = EaseLinear
+	}
+
+	// Replace any fade for the surface
+	this.fades.Lock()
+	if this.fades.fades == nil {
+		this.fades.fades = make(map[*surface]*fade)
+	} else if f, exists := this.fades.fades[surface_]; exists {
+		close(f.done)
+	}
+	f := &fade{from: from, to: to, duration: duration, easing: easing, done: make(chan struct{})}
+	this.fades.fades[surface_] = f
+	this.fades.Unlock()
+
+	// Start applying fades for each frame
+	this.start_frames()
+
+	// Return success
+	return f.done, nil
+}
+
+func (this *manager) CancelFade(s gopi.Surface) error {
+	this.log.Debug2("<graphics.surfacemanager>CancelFade{ surface=%v }", s)
+
+	if surface_, ok := s.(*surface); ok == false {
+		return gopi.ErrBadParameter
+	} else {
+		this.remove_fade(surface_)
+		return nil
+	}
+}
+
+////////////////////////////////////////////////////////////////////////////////
+// PRIVATE METHODS
+
+// fade_frame sets the opacity of each fading surface within an update,
+// and returns the fades which are complete
+func (this *manager) fade_frame(now time.Time) []*fade {
+	this.fades.Lock()
+	defer this.fades.Unlock()
+
+	complete := make([]*fade, 0, len(this.fades.fades))
+	for s, f := range this.fades.fades {
+		if f.start.IsZero() {
+			f.start = now
+		}
+		t := 1.0
+		if f.duration > 0 {
+			t = float64(now.Sub(f.start)) / float64(f.duration)
+		}
+		if t >= 1.0 {
+			t = 1.0
+			delete(this.fades.fades, s)
+			complete = append(complete, f)
+		}
+		opacity := f.from + (f.to-f.from)*float32(f.easing(t))
+		if opacity < 0.0 {
+			opacity = 0.0
+		} else if opacity > 1.0 {
+			opacity = 1.0
+		}
+		if err := this.SetOpacity(s, opacity); err != nil {
+			this.log.Warn("<graphics.surfacemanager>Fade: %v: %v", s, err)
+		}
+	}
+	return complete
+}
+
+// fading returns true if there are fades in progress
+func (this *manager) fading() bool {
+	this.fades.Lock()
+	defer this.fades.Unlock()
+	return len(this.fades.fades) > 0
+}
+
+// remove_fade cancels the fade for a surface
+func (this *manager) remove_fade(s *surface) {
+	this.fades.Lock()
+	defer this.fades.Unlock()
+	if f, exists := this.fades.fades[s]; exists {
+		delete(this.fades.fades, s)
+		close(f.done)
+	}
+}
+
+// stop_fades cancels all fades
+func (this *manager) stop_fades() {
+	this.fades.Lock()
+	defer this.fades.Unlock()
+	for s, f := range this.fades.fades {
+		delete(this.fades.fades, s)
+		close(f.done)
+	}
+}
@@ -0,0 +1,106 @@
+/*
+  Go Language Raspberry Pi Interface
+  (c) Copyright David Thorpe 2016-2019
+  All Rights Reserved
+
+  Documentation http://djthorpe.github.io/gopi/
+  For Licensing and Usage information, please see LICENSE.md
+*/
+
+package surface
+
+import (
+	"image"
+	"image/png"
+	"io"
+	"os"
+
+	// Frameworks
+	gopi "github.com/djthorpe/gopi"
+
+	// Image decoders
+	_ "image/gif"
+	_ "image/jpeg"
+	_ "image/png"
+)
+
+////////////////////////////////////////////////////////////////////////////////
+// TYPES
+
+// BitmapLoader is implemented by the surface manager in addition to
+// gopi.SurfaceManager, in order to create bitmaps from images
+type BitmapLoader interface {
+	// Create a bitmap from an image, where the flags determine the
+	// pixel format of the bitmap
+	CreateBitmapFromImage(flags gopi.SurfaceFlags, src image.Image) (gopi.Bitmap, error)
+
+	// Decode a PNG, JPEG or GIF image into a bitmap
+	OpenBitmap(r io.Reader, flags gopi.SurfaceFlags) (gopi.Bitmap, error)
+
+	// Decode a PNG, JPEG or GIF image file into a bitmap
+	OpenBitmapAtPath(path string, flags gopi.SurfaceFlags) (gopi.Bitmap, error)
+}
+
+// BitmapWriter is implemented by the surface manager in addition to
+// gopi.SurfaceManager, in order to save bitmaps and snapshots
+type BitmapWriter interface {
+	// Encode a bitmap as a PNG image
+	WritePNG(w io.Writer, bitmap gopi.Bitmap) error
+}
+
+////////////////////////////////////////////////////////////////////////////////
+// IMAGES
+
+func (this *manager) CreateBitmapFromImage(flags gopi.SurfaceFlags, src image.Image) (gopi.Bitmap, error) {
+	this.log.Debug2("<graphics.surfacemanager>CreateBitmapFromImage{ flags=%v bounds=%v }", flags, src.Bounds())
+
+	// Create a bitmap the size of the image and write the image into it,
+	// which converts premultiplied colors and pads each row to the stride
+	bounds := src.Bounds()
+	if bounds.Empty() {
+		return nil, gopi.ErrBadParameter
+	} else if bitmap, err := this.CreateBitmap(gopi.SURFACE_FLAG_BITMAP|flags.Config(), gopi.Size{float32(bounds.Dx()), float32(bounds.Dy())}); err != nil {
+		return nil, err
+	} else if bitmap_, ok := bitmap.(PixelBitmap); ok == false {
+		this.DestroyBitmap(bitmap)
+		return nil, gopi.ErrNotImplemented
+	} else if err := bitmap_.WritePixels(gopi.ZeroPoint, src); err != nil {
+		this.DestroyBitmap(bitmap)
+		return nil, err
+	} else {
+		return bitmap, nil
+	}
+}
+
+func (this *manager) OpenBitmap(r io.Reader, flags gopi.SurfaceFlags) (gopi.Bitmap, error) {
+	this.log.Debug2("<graphics.surfacemanager>OpenBitmap{ flags=%v }", flags)
+
+	if src, _, err := image.Decode(r); err != nil {
+		return nil, err
+	} else {
+		return this.CreateBitmapFromImage(flags, src)
+	}
+}
+
+func (this *manager) OpenBitmapAtPath(path string, flags gopi.SurfaceFlags) (gopi.Bitmap, error) {
+	this.log.Debug2("<graphics.surfacemanager>OpenBitmapAtPath{ path=%v flags=%v }", path, flags)
+
+	if handle, err := os.Open(path); err != nil {
+		return nil, err
+	} else {
+		defer handle.Close()
+		return this.OpenBitmap(handle, flags)
+	}
+}
+
+func (this *manager) WritePNG(w io.Writer, bitmap gopi.Bitmap) error {
+	this.log.Debug2("<graphics.surfacemanager>WritePNG{ bitmap=%v }", bitmap)
+
+	if bitmap_, ok := bitmap.(PixelBitmap); ok == false {
+		return gopi.ErrBadParameter
+	} else if img, err := bitmap_.ReadPixels(gopi.ZeroPoint, bitmap.Size()); err != nil {
+		return err
+	} else {
+		return png.Encode(w, img)
+	}
+}
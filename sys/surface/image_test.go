@@ -0,0 +1,206 @@
+// +build !rpi
+
+/*
+  Go Language Raspberry Pi Interface
+  (c) Copyright David Thorpe 2016-2019
+  All Rights Reserved
+
+  Documentation http://djthorpe.github.io/gopi/
+  For Licensing and Usage information, please see LICENSE.md
+*/
+
+package surface_test
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"image/png"
+	"path/filepath"
+	"testing"
+
+	// Frameworks
+	gopi "github.com/djthorpe/gopi"
+	surface "github.com/djthorpe/gopi-graphics/sys/surface"
+)
+
+////////////////////////////////////////////////////////////////////////////////
+// TESTS
+
+func Test_Image_000(t *testing.T) {
+	// Decode PNG and GIF images, which are lossless
+	gfx := open_manager(t)
+	defer gfx.Close()
+	tests := []struct {
+		name   string
+		pixels []color.NRGBA
+	}{
+		{"image.png", []color.NRGBA{
+			{0xFF, 0x00, 0x00, 0xFF}, {0x00, 0xFF, 0x00, 0xFF}, {0x00, 0x00, 0xFF, 0xFF}, {0xFF, 0xFF, 0xFF, 0xFF},
+			{0xFF, 0x00, 0x00, 0x80}, {0x00, 0x00, 0x00, 0x00}, {0x20, 0x40, 0x60, 0xFF}, {0x00, 0xFF, 0xFF, 0x40},
+		}},
+		{"image.gif", []color.NRGBA{
+			{0xFF, 0x00, 0x00, 0xFF}, {0x00, 0xFF, 0x00, 0xFF}, {0x00, 0x00, 0xFF, 0xFF}, {0x00, 0x00, 0x00, 0x00},
+			{0x00, 0x00, 0xFF, 0xFF}, {0x00, 0xFF, 0x00, 0xFF}, {0xFF, 0x00, 0x00, 0xFF}, {0x00, 0x00, 0x00, 0x00},
+		}},
+	}
+	for _, test := range tests {
+		bitmap, err := gfx.(surface.BitmapLoader).OpenBitmapAtPath(filepath.Join("testdata", test.name), gopi.SURFACE_FLAG_RGBA32)
+		if err != nil {
+			t.Fatal(test.name, err)
+		}
+		img := read_image(t, bitmap)
+		if bitmap.Type() != gopi.SURFACE_FLAG_RGBA32 || img.Bounds() != image.Rect(0, 0, 4, 2) {
+			t.Error(test.name, "Unexpected bitmap", bitmap)
+		}
+		for i, expected := range test.pixels {
+			if pixel := img.NRGBAAt(i%4, i/4); pixel != expected {
+				t.Errorf("%v: pixel at %v,%v expected %v, got %v", test.name, i%4, i/4, expected, pixel)
+			}
+		}
+		gfx.DestroyBitmap(bitmap)
+	}
+}
+
+func Test_Image_001(t *testing.T) {
+	// Decode a JPEG image, which is lossy, into a bitmap without alpha
+	gfx := open_manager(t)
+	defer gfx.Close()
+	bitmap, err := gfx.(surface.BitmapLoader).OpenBitmapAtPath(filepath.Join("testdata", "image.jpg"), gopi.SURFACE_FLAG_RGB888)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer gfx.DestroyBitmap(bitmap)
+	img := read_image(t, bitmap)
+	if bitmap.Type() != gopi.SURFACE_FLAG_RGB888 || img.Bounds() != image.Rect(0, 0, 16, 8) {
+		t.Error("Unexpected bitmap", bitmap)
+	}
+	for _, pixel := range []struct {
+		x, y  int
+		color color.NRGBA
+	}{
+		{2, 4, color.NRGBA{0xFF, 0x00, 0x00, 0xFF}},
+		{13, 4, color.NRGBA{0x00, 0x00, 0xFF, 0xFF}},
+	} {
+		if c := img.NRGBAAt(pixel.x, pixel.y); near(c, pixel.color, 0x10) == false {
+			t.Errorf("Pixel at %v,%v: expected %v, got %v", pixel.x, pixel.y, pixel.color, c)
+		}
+	}
+}
+
+func Test_Image_002(t *testing.T) {
+	// Convert premultiplied colors, including channels greater than
+	// alpha, and images which do not start at the origin
+	gfx := open_manager(t)
+	defer gfx.Close()
+	src := image.NewRGBA(image.Rect(5, 5, 8, 6))
+	src.SetRGBA(5, 5, color.RGBA{0x40, 0x20, 0x00, 0x80})
+	src.SetRGBA(6, 5, color.RGBA{0xFF, 0x00, 0x80, 0x80})
+	src.SetRGBA(7, 5, color.RGBA{0x10, 0x20, 0x30, 0x00})
+
+	bitmap, err := gfx.(surface.BitmapLoader).CreateBitmapFromImage(gopi.SURFACE_FLAG_RGBA32, src)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer gfx.DestroyBitmap(bitmap)
+	if bitmap.Size() != (gopi.Size{3, 1}) {
+		t.Error("Unexpected size", bitmap.Size())
+	}
+	img := read_image(t, bitmap)
+	for x, expected := range []color.NRGBA{
+		{0x7F, 0x3F, 0x00, 0x80}, // the same as color.NRGBAModel
+		{0xFF, 0x00, 0xFF, 0x80},
+		{0x00, 0x00, 0x00, 0x00},
+	} {
+		if pixel := img.NRGBAAt(x, 0); pixel != expected {
+			t.Errorf("Pixel at %v,0: expected %v, got %v", x, expected, pixel)
+		}
+	}
+
+	// Reading outside the bitmap returns the intersection
+	if img, err := bitmap.(surface.PixelBitmap).ReadPixels(gopi.Point{-2, -2}, gopi.Size{4, 4}); err != nil {
+		t.Fatal(err)
+	} else if img.Bounds() != image.Rect(0, 0, 2, 1) {
+		t.Error("Unexpected bounds", img.Bounds())
+	} else if img, err := bitmap.(surface.PixelBitmap).ReadPixels(gopi.Point{4, 0}, gopi.Size{4, 4}); err != nil {
+		t.Fatal(err)
+	} else if img.Bounds().Empty() == false {
+		t.Error("Unexpected bounds", img.Bounds())
+	}
+
+	// Empty images cannot be loaded
+	if _, err := gfx.(surface.BitmapLoader).CreateBitmapFromImage(gopi.SURFACE_FLAG_RGBA32, image.NewRGBA(image.ZR)); err != gopi.ErrBadParameter {
+		t.Error("Expected ErrBadParameter, got", err)
+	}
+}
+
+func Test_Image_003(t *testing.T) {
+	// Write bitmaps in each format to PNG and decode them again
+	gfx := open_manager(t)
+	defer gfx.Close()
+	src := image.NewNRGBA(image.Rect(0, 0, 4, 2))
+	for i, c := range []color.NRGBA{
+		{0xFF, 0x00, 0x00, 0xFF}, {0x00, 0xFF, 0x00, 0xFF}, {0x00, 0x00, 0xFF, 0xFF}, {0xFF, 0xFF, 0xFF, 0xFF},
+		{0x00, 0x00, 0x00, 0xFF}, {0xFF, 0xFF, 0x00, 0xFF}, {0xFF, 0x00, 0x00, 0x80}, {0x00, 0x00, 0x00, 0x00},
+	} {
+		src.SetNRGBA(i%4, i/4, c)
+	}
+	for _, config := range []gopi.SurfaceFlags{gopi.SURFACE_FLAG_RGBA32, gopi.SURFACE_FLAG_RGB888, gopi.SURFACE_FLAG_RGB565} {
+		bitmap, err := gfx.(surface.BitmapLoader).CreateBitmapFromImage(config, src)
+		if err != nil {
+			t.Fatal(config, err)
+		}
+		buf := new(bytes.Buffer)
+		if err := gfx.(surface.BitmapWriter).WritePNG(buf, bitmap); err != nil {
+			t.Fatal(config, err)
+		}
+		gfx.DestroyBitmap(bitmap)
+		img, err := png.Decode(buf)
+		if err != nil {
+			t.Fatal(config, err)
+		} else if img.Bounds() != src.Bounds() {
+			t.Error(config, "Unexpected bounds", img.Bounds())
+			continue
+		}
+		for y := 0; y < 2; y++ {
+			for x := 0; x < 4; x++ {
+				// Formats without alpha are opaque and keep the color
+				expected := src.NRGBAAt(x, y)
+				if config != gopi.SURFACE_FLAG_RGBA32 {
+					expected.A = 0xFF
+				}
+				if pixel := color.NRGBAModel.Convert(img.At(x, y)).(color.NRGBA); pixel != expected {
+					t.Errorf("%v: pixel at %v,%v expected %v, got %v", config, x, y, expected, pixel)
+				}
+			}
+		}
+	}
+
+	// Bitmaps which cannot be read are rejected
+	if err := gfx.(surface.BitmapWriter).WritePNG(new(bytes.Buffer), nil); err != gopi.ErrBadParameter {
+		t.Error("Expected ErrBadParameter, got", err)
+	}
+}
+
+////////////////////////////////////////////////////////////////////////////////
+// PRIVATE METHODS
+
+func read_image(t *testing.T, bitmap gopi.Bitmap) *image.NRGBA {
+	t.Helper()
+	if img, err := bitmap.(surface.PixelBitmap).ReadPixels(gopi.ZeroPoint, bitmap.Size()); err != nil {
+		t.Fatal(err)
+	} else {
+		return img
+	}
+	return nil
+}
+
+// near returns true if each channel of two colors is within a tolerance
+func near(a, b color.NRGBA, tolerance int) bool {
+	for _, delta := range []int{int(a.R) - int(b.R), int(a.G) - int(b.G), int(a.B) - int(b.B), int(a.A) - int(b.A)} {
+		if delta < -tolerance || delta > tolerance {
+			return false
+		}
+	}
+	return true
+}
@@ -1,5 +1,3 @@
-// +build rpi
-
 /*
   Go Language Raspberry Pi Interface
   (c) Copyright David Thorpe 2016-2019
@@ -24,10 +22,21 @@ func init() {
 		Name:     "graphics/surfaces",
 		Type:     gopi.MODULE_TYPE_GRAPHICS,
 		Requires: []string{"display"},
+		Config: func(config *gopi.AppConfig) {
+			config.AppFlags.FlagUint("graphics.rotate", 0, "Display rotation in degrees (0, 90, 180, 270)")
+		},
 		New: func(app *gopi.AppInstance) (gopi.Driver, error) {
-			return gopi.Open(SurfaceManager{
+			config := SurfaceManager{
 				Display: app.Display,
-			}, app.Logger)
+			}
+			if degrees, exists := app.AppFlags.GetUint("graphics.rotate"); exists {
+				if transform, err := TransformForRotation(degrees); err != nil {
+					return nil, err
+				} else {
+					config.Transform = transform
+				}
+			}
+			return gopi.Open(config, app.Logger)
 		},
 	})
 }
@@ -13,8 +13,10 @@ package surface
 
 import (
 	"fmt"
+	"image"
 	"strings"
 	"sync"
+	"time"
 	"unsafe"
 
 	// Frameworks
@@ -29,6 +31,10 @@ import (
 
 type SurfaceManager struct {
 	Display gopi.Display
+
+	// Rotation and flipping applied to every surface and to
+	// snapshots, for displays which are not mounted upright
+	Transform Transform
 }
 
 type manager struct {
@@ -39,18 +45,32 @@ type manager struct {
 	surfaces     []*surface
 	bitmaps      []*bitmap
 	update       rpi.DX_Update
+	transform    Transform
+	fades        fades
+	frames       frames
+
+	// Set when an update has been submitted since the last wait
+	// for a refresh
+	submitted bool
+
+	// Serializes the start and end of updates between Do and frames
+	updating sync.Mutex
+
 	sync.Mutex
 }
 
 type surface struct {
-	log     gopi.Logger
-	flags   gopi.SurfaceFlags
-	opacity float32
-	layer   uint16
-	context egl.EGL_Context
-	handle  egl.EGL_Surface
-	native  *nativesurface
-	bitmap  gopi.Bitmap
+	log       gopi.Logger
+	flags     gopi.SurfaceFlags
+	opacity   float32
+	layer     uint16
+	transform Transform
+	key       ChromaKey
+	context   egl.EGL_Context
+	handle    egl.EGL_Surface
+	native    *nativesurface
+	bitmap    gopi.Bitmap
+	owned     gopi.Bitmap
 }
 
 type bitmap struct {
@@ -78,16 +98,27 @@ type bitmap_565 struct {
 }
 
 type nativesurface struct {
-	handle rpi.DX_Element
-	size   rpi.DX_Size
-	origin rpi.DX_Point
+	handle     rpi.DX_Element
+	size       rpi.DX_Size
+	origin     rpi.DX_Point
+	src_size   rpi.DX_Size
+	src_origin rpi.DX_Point
 }
 
+////////////////////////////////////////////////////////////////////////////////
+// CONSTANTS
+
+const (
+	// Flags of DISPMANX_TRANSFORM_T which gopi-hw does not define
+	DISPMANX_FLIP_HRIZ rpi.DX_Transform = 1 << 16
+	DISPMANX_FLIP_VERT rpi.DX_Transform = 1 << 17
+)
+
 ////////////////////////////////////////////////////////////////////////////////
 // OPEN AND CLOSE
 
 func (config SurfaceManager) Open(log gopi.Logger) (gopi.Driver, error) {
-	log.Debug("<graphics.surfacemanager.Open>{ display=%v }", config.Display)
+	log.Debug("<graphics.surfacemanager.Open>{ display=%v transform=%v }", config.Display, config.Transform)
 
 	this := new(manager)
 	this.log = log
@@ -96,6 +127,14 @@ func (config SurfaceManager) Open(log gopi.Logger) (gopi.Driver, error) {
 	this.display = config.Display
 	if this.display == nil {
 		return nil, gopi.ErrBadParameter
+	} else if _, ok := this.display.(display.NativeDisplay); ok == false {
+		// Virtual displays cannot be used with DispmanX
+		return nil, gopi.ErrBadParameter
+	}
+
+	// Check transform
+	if this.transform = config.Transform; this.transform > SURFACE_TRANSFORM_MAX {
+		return nil, gopi.ErrBadParameter
 	}
 
 	// Initialize EGL
@@ -124,9 +163,13 @@ func (this *manager) Close() error {
 		return nil
 	}
 
-	// Free Surfaces
+	// Cancel fades and frame callbacks
+	this.stop_fades()
+	this.stop_frames()
+
+	// Free Surfaces, which are removed from the list as they are destroyed
 	if err := this.Do(func(gopi.SurfaceManager) error {
-		for _, surface := range this.surfaces {
+		for _, surface := range append([]*surface{}, this.surfaces...) {
 			if err := this.DestroySurface(surface); err != nil {
 				return err
 			}
@@ -137,7 +180,7 @@ func (this *manager) Close() error {
 	}
 
 	// Free Bitmaps
-	for _, bitmap := range this.bitmaps {
+	for _, bitmap := range append([]*bitmap{}, this.bitmaps...) {
 		if err := this.DestroyBitmap(bitmap); err != nil {
 			return err
 		}
@@ -221,8 +264,11 @@ func (this *manager) CreateSurface(flags gopi.SurfaceFlags, opacity float32, lay
 		if bitmap, err := this.CreateBitmap(flags, size); err != nil {
 			return nil, err
 		} else if surface, err := this.CreateSurfaceWithBitmap(bitmap, flags, opacity, layer, origin, size); err != nil {
+			this.DestroyBitmap(bitmap)
 			return nil, err
 		} else {
+			// The bitmap is destroyed with the surface
+			this.set_owned(surface, bitmap)
 			return surface, nil
 		}
 	}
@@ -256,13 +302,13 @@ func (this *manager) CreateSurface(flags gopi.SurfaceFlags, opacity float32, lay
 		return nil, gopi.ErrBadParameter
 	} else if opacity < 0.0 || opacity > 1.0 {
 		return nil, gopi.ErrBadParameter
-	} else if layer < gopi.SURFACE_LAYER_DEFAULT || layer > gopi.SURFACE_LAYER_MAX {
+	} else if layer_is_valid(layer) == false {
 		return nil, gopi.ErrBadParameter
 	} else if err := egl.EGL_BindAPI(api_); err != nil {
 		return nil, err
 	} else if config, err := egl.EGL_ChooseConfig(this.handle, r, g, b, a, egl.EGL_SURFACETYPE_FLAG_WINDOW, renderable_); err != nil {
 		return nil, err
-	} else if native_surface, err := this.CreateNativeSurface(nil, flags, opacity, layer, origin, size); err != nil {
+	} else if native_surface, err := this.CreateNativeSurface(nil, flags, opacity, layer, origin, size, SURFACE_TRANSFORM_NONE); err != nil {
 		return nil, err
 	} else if handle, err := egl.EGL_CreateSurface(this.handle, config, egl_nativewindow(native_surface)); err != nil {
 		// TODO: Destroy native surface
@@ -283,35 +329,43 @@ func (this *manager) CreateSurface(flags gopi.SurfaceFlags, opacity float32, lay
 			handle:  handle,
 			native:  native_surface,
 		}
-		this.surfaces = append(this.surfaces, s)
+		this.add_surface(s)
 		return s, nil
 	}
 }
 
 func (this *manager) CreateSurfaceWithBitmap(bitmap gopi.Bitmap, flags gopi.SurfaceFlags, opacity float32, layer uint16, origin gopi.Point, size gopi.Size) (gopi.Surface, error) {
+	return this.CreateSurfaceWithTransform(bitmap, flags, opacity, layer, origin, size, SURFACE_TRANSFORM_NONE)
+}
+
+func (this *manager) CreateSurfaceWithTransform(bitmap gopi.Bitmap, flags gopi.SurfaceFlags, opacity float32, layer uint16, origin gopi.Point, size gopi.Size, transform Transform) (gopi.Surface, error) {
+	if bitmap == nil {
+		return nil, gopi.ErrBadParameter
+	}
 	flags = gopi.SURFACE_FLAG_BITMAP | bitmap.Type() | flags.Mod()
-	this.log.Debug2("<graphics.surfacemanager>CreateSurfaceWithBitmap{ bitmap=%v flags=%v opacity=%v layer=%v origin=%v size=%v }", bitmap, flags, opacity, layer, origin, size)
+	this.log.Debug2("<graphics.surfacemanager>CreateSurfaceWithTransform{ bitmap=%v flags=%v opacity=%v layer=%v origin=%v size=%v transform=%v }", bitmap, flags, opacity, layer, origin, size, transform)
 	if opacity < 0.0 || opacity > 1.0 {
 		return nil, gopi.ErrBadParameter
-	} else if layer < gopi.SURFACE_LAYER_DEFAULT || layer > gopi.SURFACE_LAYER_MAX {
+	} else if layer_is_valid(layer) == false {
 		return nil, gopi.ErrBadParameter
-	} else if bitmap == nil {
+	} else if transform > SURFACE_TRANSFORM_MAX {
 		return nil, gopi.ErrBadParameter
-	} else if size = size_from_bitmap(bitmap, size); size == gopi.ZeroSize {
+	} else if size = size_from_bitmap(bitmap, size, transform); size == gopi.ZeroSize {
 		return nil, gopi.ErrBadParameter
-	} else if native_surface, err := this.CreateNativeSurface(bitmap, flags, opacity, layer, origin, size); err != nil {
+	} else if native_surface, err := this.CreateNativeSurface(bitmap, flags, opacity, layer, origin, size, transform); err != nil {
 		return nil, err
 	} else {
 		// Return the surface
 		s := &surface{
-			log:     this.log,
-			flags:   flags,
-			opacity: opacity,
-			layer:   layer,
-			native:  native_surface,
-			bitmap:  bitmap,
+			log:       this.log,
+			flags:     flags,
+			opacity:   opacity,
+			layer:     layer,
+			transform: transform,
+			native:    native_surface,
 		}
-		this.surfaces = append(this.surfaces, s)
+		s.set_bitmap(bitmap)
+		this.add_surface(s)
 		return s, nil
 	}
 }
@@ -343,14 +397,21 @@ func (this *manager) DestroySurface(s gopi.Surface) error {
 				surface_.native = nil
 			}
 		}
+		// Release the bitmap, and destroy the bitmap created with the surface
+		surface_.set_bitmap(nil)
+		if err := this.destroy_owned(surface_); err != nil {
+			return err
+		}
+		this.remove_surface(surface_)
+		this.remove_fade(surface_)
 	}
 
 	// Return success
 	return nil
 }
 
-func (this *manager) CreateNativeSurface(b gopi.Bitmap, flags gopi.SurfaceFlags, opacity float32, layer uint16, origin gopi.Point, size gopi.Size) (*nativesurface, error) {
-	this.log.Debug2("<graphics.surfacemanager>CreateNativeSurface{ bitmap=%v flags=%v opacity=%v layer=%v origin=%v size=%v }", b, flags, opacity, layer, origin, size)
+func (this *manager) CreateNativeSurface(b gopi.Bitmap, flags gopi.SurfaceFlags, opacity float32, layer uint16, origin gopi.Point, size gopi.Size, transform Transform) (*nativesurface, error) {
+	this.log.Debug2("<graphics.surfacemanager>CreateNativeSurface{ bitmap=%v flags=%v opacity=%v layer=%v origin=%v size=%v transform=%v }", b, flags, opacity, layer, origin, size, transform)
 
 	// If no update, then return out of order error
 	this.Lock()
@@ -360,25 +421,21 @@ func (this *manager) CreateNativeSurface(b gopi.Bitmap, flags gopi.SurfaceFlags,
 	}
 
 	// Set alpha
-	alpha := rpi.DX_Alpha{
-		Opacity: uint32(opacity_from_float(opacity)),
-	}
-	if flags.Mod()&gopi.SURFACE_FLAG_ALPHA_FROM_SOURCE != 0 {
-		alpha.Flags |= rpi.DX_ALPHA_FLAG_FROM_SOURCE
-	} else {
-		alpha.Flags |= rpi.DX_ALPHA_FLAG_FIXED_ALL_PIXELS
-	}
+	alpha := rpi_dx_alpha(flags, opacity)
 
-	// Clamp, transform and protection
+	// Clamp and protection
 	clamp := rpi.DX_Clamp{}
-	transform := rpi.DX_TRANSFORM_NONE
 	protection := rpi.DX_PROTECTION_NONE
 
-	// If there is a bitmap, then the source rectangle is set from that
-	dest_rect := rpi.DX_NewRect(int32(origin.X), int32(origin.Y), uint32(size.W), uint32(size.H))
-	src_size := rpi.DX_RectSize(dest_rect)
-	dest_size := rpi.DX_RectSize(dest_rect)
-	dest_origin := rpi.DX_RectOrigin(dest_rect)
+	// If there is a bitmap, then the source rectangle is set from that. The
+	// destination rectangle has the display transform applied
+	dest_size := rpi.DX_Size{uint32(size.W), uint32(size.H)}
+	dest_origin := rpi.DX_Point{int32(origin.X), int32(origin.Y)}
+	dest_rect := this.dest_rect(dest_origin, dest_size)
+	src_size := rpi.DX_Size{dest_size.W, dest_size.H}
+	if transform.Swaps() {
+		src_size = rpi.DX_Size{dest_size.H, dest_size.W}
+	}
 
 	// Check size - uint16
 	if src_size.W > 0xFFFF || src_size.H > 0xFFFF {
@@ -388,25 +445,22 @@ func (this *manager) CreateNativeSurface(b gopi.Bitmap, flags gopi.SurfaceFlags,
 		return nil, gopi.ErrBadParameter
 	}
 
-	// Adjust size for source
-	src_size.W = src_size.W << 16
-	src_size.H = src_size.H << 16
-
-	// Get source resource
+	// Get source resource, which is scaled to the size of the surface
 	src_resource := rpi.DX_Resource(0)
 	if b != nil {
 		if bitmap_, ok := b.(*bitmap); ok == false {
 			return nil, gopi.ErrBadParameter
 		} else {
 			src_resource = bitmap_.handle
+			src_size = bitmap_.size
 		}
 	}
 
-	// Create the element
-	if handle, err := rpi.DX_ElementAdd(this.update, rpi_dx_display(this.display), layer, dest_rect, src_resource, src_size, protection, alpha, clamp, transform); err != nil {
+	// Create the element, where the source size is in pixels
+	if handle, err := rpi.DX_ElementAdd(this.update, rpi_dx_display(this.display), layer, dest_rect, src_resource, src_size, protection, alpha, clamp, rpi_dx_transform(transform.Then(this.transform))); err != nil {
 		return nil, err
 	} else {
-		return &nativesurface{handle, dest_size, dest_origin}, nil
+		return &nativesurface{handle, dest_size, dest_origin, src_size, rpi.DX_Point{}}, nil
 	}
 }
 
@@ -470,7 +524,7 @@ func (this *manager) CreateBitmap(flags gopi.SurfaceFlags, size gopi.Size) (gopi
 	} else {
 		b.handle = handle
 		b.stride = rpi.DX_AlignUp(b.size.W, 16) * b.bytes_per_pixel
-		this.bitmaps = append(this.bitmaps, b)
+		this.add_bitmap(b)
 		return b, nil
 	}
 
@@ -481,12 +535,17 @@ func (this *manager) DestroyBitmap(b gopi.Bitmap) error {
 
 	if bitmap_, ok := b.(*bitmap); ok == false {
 		return gopi.ErrBadParameter
-	} else if bitmap_.handle != 0 {
-		if err := rpi.DX_ResourceDelete(bitmap_.handle); err != nil {
-			return err
-		} else {
-			bitmap_.handle = 0
+	} else if bitmap_.in_use() {
+		return ErrBitmapInUse
+	} else {
+		if bitmap_.handle != 0 {
+			if err := rpi.DX_ResourceDelete(bitmap_.handle); err != nil {
+				return err
+			} else {
+				bitmap_.handle = 0
+			}
 		}
+		this.remove_bitmap(bitmap_)
 	}
 
 	// Success
@@ -495,8 +554,8 @@ func (this *manager) DestroyBitmap(b gopi.Bitmap) error {
 
 func (this *manager) CreateSnapshot(flags gopi.SurfaceFlags) (gopi.Bitmap, error) {
 	flags = gopi.SURFACE_FLAG_BITMAP | flags.Config() | flags.Mod()
-	w, h := this.Display().Size()
-	size := gopi.Size{float32(w), float32(h)}
+	display_size := this.display_size()
+	size := gopi.Size{float32(display_size.X), float32(display_size.Y)}
 
 	this.log.Debug2("<graphics.surfacemanager>CreateSnapshot{ flags=%v size=%v }", flags, size)
 
@@ -504,7 +563,7 @@ func (this *manager) CreateSnapshot(flags gopi.SurfaceFlags) (gopi.Bitmap, error
 		return nil, err
 	} else if bitmap_, ok := b.(*bitmap); ok == false {
 		return nil, gopi.ErrAppError
-	} else if err := rpi.DX_DisplaySnapshot(rpi_dx_display(this.display), bitmap_.handle, rpi.DX_TRANSFORM_NONE); err != nil {
+	} else if err := rpi.DX_DisplaySnapshot(rpi_dx_display(this.display), bitmap_.handle, rpi_dx_transform(this.transform.Inverse())); err != nil {
 		return nil, err
 	} else {
 		return bitmap_, nil
@@ -532,14 +591,142 @@ func rpi_dx_display(d gopi.Display) rpi.DX_DisplayHandle {
 	return d.(display.NativeDisplay).Handle()
 }
 
-func size_from_bitmap(bitmap gopi.Bitmap, size gopi.Size) gopi.Size {
-	if size == gopi.ZeroSize {
-		return bitmap.Size()
+func size_from_bitmap(bitmap gopi.Bitmap, size gopi.Size, transform Transform) gopi.Size {
+	if size != gopi.ZeroSize {
+		return size
+	} else if size := bitmap.Size(); transform.Swaps() {
+		return gopi.Size{size.H, size.W}
 	} else {
 		return size
 	}
 }
 
+// set_viewport changes the source rectangle for an element, which is
+// 16.16 fixed point
+func (this *manager) set_viewport(s gopi.Surface, rect image.Rectangle) error {
+	if surface_, ok := s.(*surface); ok == false || surface_.native == nil {
+		return gopi.ErrBadParameter
+	} else if surface_.bitmap == nil || viewport_is_valid(rect, surface_.bitmap) == false {
+		return gopi.ErrBadParameter
+	} else if src_rect := rpi.DX_NewRect(int32(rect.Min.X)<<16, int32(rect.Min.Y)<<16, uint32(rect.Dx())<<16, uint32(rect.Dy())<<16); src_rect == nil {
+		return gopi.ErrBadParameter
+	} else if err := rpi.DX_ElementChangeAttributes(this.update, surface_.native.handle, rpi.DX_CHANGE_FLAG_SRC_RECT, 0, 0, nil, src_rect, 0); err != nil {
+		return err
+	} else {
+		surface_.native.src_origin = rpi.DX_Point{int32(rect.Min.X), int32(rect.Min.Y)}
+		surface_.native.src_size = rpi.DX_Size{uint32(rect.Dx()), uint32(rect.Dy())}
+		return nil
+	}
+}
+
+// set_chroma_key sets the clamp for a surface. The clamp of an element
+// cannot be changed, so the element is replaced with a new element
+func (this *manager) set_chroma_key(s *surface, key ChromaKey) error {
+	bitmap_, _ := s.bitmap.(*bitmap)
+	if clamp, err := rpi_dx_clamp(key); err != nil {
+		return err
+	} else if err := this.replace_element(s, bitmap_, clamp); err != nil {
+		return err
+	} else {
+		s.key = key
+		return nil
+	}
+}
+
+// replace_element adds an element with the same attributes as the element
+// for a bitmap surface but a different bitmap or clamp, and removes the
+// existing element within the same update, since neither can be changed
+// on an existing element
+func (this *manager) replace_element(s *surface, bitmap_ *bitmap, clamp rpi.DX_Clamp) error {
+	native := s.native
+	if bitmap_ == nil || native == nil {
+		return gopi.ErrBadParameter
+	}
+
+	// Add the element and then set the source rectangle, which is 16.16
+	// fixed point
+	dest_rect := this.dest_rect(native.origin, native.size)
+	src_rect := rpi.DX_NewRect(native.src_origin.X<<16, native.src_origin.Y<<16, native.src_size.W<<16, native.src_size.H<<16)
+	handle, err := rpi.DX_ElementAdd(this.update, rpi_dx_display(this.display), s.layer, dest_rect, bitmap_.handle, bitmap_.size, rpi.DX_PROTECTION_NONE, rpi_dx_alpha(s.flags, s.opacity), clamp, rpi_dx_transform(s.transform.Then(this.transform)))
+	if err != nil {
+		return err
+	} else if err := rpi.DX_ElementChangeAttributes(this.update, handle, rpi.DX_CHANGE_FLAG_SRC_RECT, 0, 0, nil, src_rect, 0); err != nil {
+		rpi.DX_ElementRemove(this.update, handle)
+		return err
+	} else if err := rpi.DX_ElementRemove(this.update, native.handle); err != nil {
+		rpi.DX_ElementRemove(this.update, handle)
+		return err
+	} else {
+		native.handle = handle
+		return nil
+	}
+}
+
+func rpi_dx_alpha(flags gopi.SurfaceFlags, opacity float32) rpi.DX_Alpha {
+	alpha := rpi.DX_Alpha{
+		Opacity: uint32(opacity_from_float(opacity)),
+	}
+	if flags.Mod()&gopi.SURFACE_FLAG_ALPHA_FROM_SOURCE != 0 {
+		alpha.Flags |= rpi.DX_ALPHA_FLAG_FROM_SOURCE
+	} else {
+		alpha.Flags |= rpi.DX_ALPHA_FLAG_FIXED_ALL_PIXELS
+	}
+	return alpha
+}
+
+// rpi_dx_clamp returns the DispmanX clamp for a key. DX_Clamp has no field
+// for the key values of DISPMANX_CLAMP_T, which are the upper and then lower
+// values of red, blue and green and are laid out over the Opacity field and
+// the first two bytes of the Mask field on the 32-bit little-endian
+// Raspberry Pi. Only RGB keys are supported
+func rpi_dx_clamp(key ChromaKey) (rpi.DX_Clamp, error) {
+	const r, g, b = 0, 1, 2
+	switch key.Mode {
+	case CHROMA_KEY_NONE:
+		return rpi.DX_Clamp{Mode: rpi.DX_CLAMP_MODE_NONE}, nil
+	case CHROMA_KEY_RGB:
+		return rpi.DX_Clamp{
+			Mode:    rpi.DX_CLAMP_MODE_CHROMA_TRANSPARENT,
+			Opacity: uint32(key.Upper[r]) | uint32(key.Lower[r])<<8 | uint32(key.Upper[b])<<16 | uint32(key.Lower[b])<<24,
+			Mask:    rpi.DX_Resource(uint32(key.Upper[g]) | uint32(key.Lower[g])<<8),
+		}, nil
+	default:
+		return rpi.DX_Clamp{}, gopi.ErrNotImplemented
+	}
+}
+
+// rpi_dx_transform returns the DispmanX transform for a transform. DispmanX
+// rotates and then flips, where a transform flips and then rotates, so a
+// flipped transform has the opposite rotation. The transform is simplified
+// first so that it has at most a horizontal flip
+func rpi_dx_transform(transform Transform) rpi.DX_Transform {
+	transform = transform_from_matrix(transform.matrix())
+	if transform&SURFACE_TRANSFORM_FLIP_H != 0 {
+		transform = SURFACE_TRANSFORM_FLIP_H | (transform & SURFACE_TRANSFORM_ROTATE).Inverse()
+	}
+	dx_transform := rpi.DX_TRANSFORM_NONE
+	switch transform & SURFACE_TRANSFORM_ROTATE {
+	case SURFACE_TRANSFORM_ROTATE_90:
+		dx_transform |= rpi.DX_TRANSFORM_ROTATE_90
+	case SURFACE_TRANSFORM_ROTATE_180:
+		dx_transform |= rpi.DX_TRANSFORM_ROTATE_180
+	case SURFACE_TRANSFORM_ROTATE_270:
+		dx_transform |= rpi.DX_TRANSFORM_ROTATE_270
+	}
+	if transform&SURFACE_TRANSFORM_FLIP_H != 0 {
+		dx_transform |= DISPMANX_FLIP_HRIZ
+	}
+	return dx_transform
+}
+
+// dest_rect returns the destination rectangle for an element with the
+// display transform applied
+func (this *manager) dest_rect(origin rpi.DX_Point, size rpi.DX_Size) rpi.DX_Rect {
+	rect := image.Rect(int(origin.X), int(origin.Y), int(origin.X)+int(size.W), int(origin.Y)+int(size.H))
+	rect = transform_rect(this.transform, this.display_size(), rect)
+	return rpi.DX_NewRect(int32(rect.Min.X), int32(rect.Min.Y), uint32(rect.Dx()), uint32(rect.Dy()))
+}
+
 ////////////////////////////////////////////////////////////////////////////////
 // UPDATES
 
@@ -547,32 +734,76 @@ func (this *manager) Do(callback gopi.SurfaceManagerCallback) error {
 	if this.handle == nil {
 		return gopi.ErrBadParameter
 	}
-	if callback == nil {
-		return gopi.ErrBadParameter
-	}
-	if this.update != 0 {
-		return gopi.ErrOutOfOrder
+	_, err := this.do(callback, false)
+	return err
+}
+
+func (this *manager) DoAsync(callback gopi.SurfaceManagerCallback) (<-chan error, error) {
+	if this.handle == nil {
+		return nil, gopi.ErrBadParameter
 	}
+	return this.do(callback, true)
+}
+
+func (this *manager) in_update() bool {
+	this.Lock()
+	defer this.Unlock()
+	return this.update != 0
+}
+
+func (this *manager) begin_update() error {
+	this.Lock()
+	defer this.Unlock()
 	// TODO rpi.DX_UPDATE_PRIORITY_DEFAULT
 	if update, err := rpi.DX_UpdateStart(0); err != nil {
 		return err
 	} else {
 		this.update = update
-		defer func() {
-			if rpi.DX_UpdateSubmitSync(update); err != nil {
-				this.log.Warn("Do: %v", err)
-			}
-			this.update = 0
-		}()
-		if err := callback(this); err != nil {
-			return err
-		}
+		return nil
 	}
+}
 
-	// Return success
+// end_update submits the update and waits for the display to
+// take the update
+func (this *manager) end_update() error {
+	this.Lock()
+	defer this.Unlock()
+	update := this.update
+	this.update = 0
+	if err := rpi.DX_UpdateSubmitSync(update); err != nil {
+		return err
+	}
+	this.submitted = true
 	return nil
 }
 
+// wait_vsync returns immediately when an update was submitted since the
+// last call, since submitting an update waits for the display to take the
+// update on the next refresh. Otherwise it waits for the expected refresh
+// interval before returning the time, or returns false if stopped
+func (this *manager) wait_vsync(stop <-chan struct{}) (time.Time, bool) {
+	this.Lock()
+	submitted := this.submitted
+	this.submitted = false
+	this.Unlock()
+	if submitted {
+		select {
+		case <-stop:
+			return time.Time{}, false
+		default:
+			return time.Now(), true
+		}
+	}
+	timer := time.NewTimer(time.Second / REFRESH_RATE_DEFAULT)
+	defer timer.Stop()
+	select {
+	case <-stop:
+		return time.Time{}, false
+	case now := <-timer.C:
+		return now, true
+	}
+}
+
 ////////////////////////////////////////////////////////////////////////////////
 // MOVE SURFACES
 
@@ -591,7 +822,7 @@ func (this *manager) SetOrigin(s gopi.Surface, origin gopi.Point) error {
 
 	if surface_, ok := s.(*surface); ok == false {
 		return gopi.ErrBadParameter
-	} else if dest_rect := rpi.DX_NewRect(dx_origin.X, dx_origin.Y, surface_.native.size.W, surface_.native.size.H); dest_rect == nil {
+	} else if dest_rect := this.dest_rect(dx_origin, surface_.native.size); dest_rect == nil {
 		return gopi.ErrBadParameter
 	} else if err := rpi.DX_ElementChangeAttributes(this.update, surface_.native.handle, rpi.DX_CHANGE_FLAG_DEST_RECT, 0, 0, dest_rect, nil, 0); err != nil {
 		return err
@@ -611,14 +842,17 @@ func (this *manager) MoveOriginBy(s gopi.Surface, increment gopi.Point) error {
 		return gopi.ErrOutOfOrder
 	}
 
-	if surface_, ok := s.(*surface); ok == false {
+	surface_, ok := s.(*surface)
+	if ok == false {
 		return gopi.ErrBadParameter
-	} else if dest_rect := rpi.DX_NewRect(surface_.native.origin.X+int32(increment.X), surface_.native.origin.Y+int32(increment.Y), surface_.native.size.W, surface_.native.size.H); dest_rect == nil {
+	}
+	dx_origin := rpi.DX_Point{surface_.native.origin.X + int32(increment.X), surface_.native.origin.Y + int32(increment.Y)}
+	if dest_rect := this.dest_rect(dx_origin, surface_.native.size); dest_rect == nil {
 		return gopi.ErrBadParameter
 	} else if err := rpi.DX_ElementChangeAttributes(this.update, surface_.native.handle, rpi.DX_CHANGE_FLAG_DEST_RECT, 0, 0, dest_rect, nil, 0); err != nil {
 		return err
 	} else {
-		surface_.native.origin = rpi.DX_RectOrigin(dest_rect)
+		surface_.native.origin = dx_origin
 		return nil
 	}
 }
@@ -663,7 +897,7 @@ func (this *manager) SetOpacity(s gopi.Surface, opacity float32) error {
 		return gopi.ErrBadParameter
 	} else if opacity < 0.0 || opacity > 1.0 {
 		return gopi.ErrBadParameter
-	} else if err := rpi.DX_ElementChangeAttributes(this.update, surface_.native.handle, rpi.DX_CHANGE_FLAG_LAYER, 0, opacity_from_float(opacity), nil, nil, 0); err != nil {
+	} else if err := rpi.DX_ElementChangeAttributes(this.update, surface_.native.handle, rpi.DX_CHANGE_FLAG_OPACITY, 0, opacity_from_float(opacity), nil, nil, 0); err != nil {
 		return err
 	} else {
 		surface_.opacity = opacity
@@ -671,13 +905,169 @@ func (this *manager) SetOpacity(s gopi.Surface, opacity float32) error {
 	}
 }
 
-////////////////////////////////////////////////////////////////////////////////
-// UNIMPLEMENTED
+func (this *manager) SetSize(s gopi.Surface, size gopi.Size) error {
+	return this.SetSizeWithMode(s, size, SURFACE_SIZE_SCALE)
+}
 
-func (this *manager) SetSize(gopi.Surface, gopi.Size) error {
-	return gopi.ErrNotImplemented
+func (this *manager) SetSizeWithMode(s gopi.Surface, size gopi.Size, mode SizeMode) error {
+	this.log.Debug2("<graphics.surfacemanager>SetSizeWithMode{ surface=%v size=%v mode=%v }", s, size, mode)
+
+	// If no update, then return out of order error
+	this.Lock()
+	defer this.Unlock()
+	if this.update == 0 {
+		return gopi.ErrOutOfOrder
+	}
+
+	// Check size - uint16
+	dest_size := rpi.DX_Size{uint32(size.W), uint32(size.H)}
+	if dest_size.W == 0 || dest_size.H == 0 {
+		return gopi.ErrBadParameter
+	} else if dest_size.W > 0xFFFF || dest_size.H > 0xFFFF {
+		return gopi.ErrBadParameter
+	}
+
+	surface_, ok := s.(*surface)
+	if ok == false {
+		return gopi.ErrBadParameter
+	}
+	bitmap_, ok := surface_.bitmap.(*bitmap)
+	if ok == false {
+		return gopi.ErrBadParameter
+	}
+
+	// Determine the source rectangle
+	native := surface_.native
+	src_size := native.src_size
+	switch mode {
+	case SURFACE_SIZE_SCALE:
+		break
+	case SURFACE_SIZE_CLIP:
+		// The source rectangle is the same size as the surface
+		// before the transform is applied
+		if src_size = dest_size; surface_.transform.Swaps() {
+			src_size = rpi.DX_Size{dest_size.H, dest_size.W}
+		}
+		if uint32(native.src_origin.X)+src_size.W > bitmap_.size.W || uint32(native.src_origin.Y)+src_size.H > bitmap_.size.H {
+			return gopi.ErrBadParameter
+		}
+	default:
+		return gopi.ErrBadParameter
+	}
+
+	// Change the destination and source rectangles, where the source
+	// rectangle is 16.16 fixed point
+	dest_rect := this.dest_rect(native.origin, dest_size)
+	src_rect := rpi.DX_NewRect(native.src_origin.X<<16, native.src_origin.Y<<16, src_size.W<<16, src_size.H<<16)
+	if err := rpi.DX_ElementChangeAttributes(this.update, native.handle, rpi.DX_CHANGE_FLAG_DEST_RECT|rpi.DX_CHANGE_FLAG_SRC_RECT, 0, 0, dest_rect, src_rect, 0); err != nil {
+		return err
+	} else {
+		native.size = dest_size
+		native.src_size = src_size
+		return nil
+	}
+}
+
+func (this *manager) SetTransform(s gopi.Surface, transform Transform) error {
+	this.log.Debug2("<graphics.surfacemanager>SetTransform{ surface=%v transform=%v }", s, transform)
+
+	// If no update, then return out of order error
+	this.Lock()
+	defer this.Unlock()
+	if this.update == 0 {
+		return gopi.ErrOutOfOrder
+	}
+
+	if surface_, ok := s.(*surface); ok == false || surface_.native == nil {
+		return gopi.ErrBadParameter
+	} else if transform > SURFACE_TRANSFORM_MAX {
+		return gopi.ErrBadParameter
+	} else if err := rpi.DX_ElementChangeAttributes(this.update, surface_.native.handle, rpi.DX_CHANGE_FLAG_TRANSFORM, 0, 0, nil, nil, rpi_dx_transform(transform.Then(this.transform))); err != nil {
+		return err
+	} else {
+		surface_.transform = transform
+		return nil
+	}
 }
 
+func (this *manager) SetViewport(s gopi.Surface, rect image.Rectangle) error {
+	this.log.Debug2("<graphics.surfacemanager>SetViewport{ surface=%v rect=%v }", s, rect)
+
+	// If no update, then return out of order error
+	this.Lock()
+	defer this.Unlock()
+	if this.update == 0 {
+		return gopi.ErrOutOfOrder
+	}
+
+	return this.set_viewport(s, rect)
+}
+
+func (this *manager) MoveViewportBy(s gopi.Surface, delta gopi.Point) error {
+	this.log.Debug2("<graphics.surfacemanager>MoveViewportBy{ surface=%v delta=%v }", s, delta)
+
+	// If no update, then return out of order error
+	this.Lock()
+	defer this.Unlock()
+	if this.update == 0 {
+		return gopi.ErrOutOfOrder
+	}
+
+	return this.set_viewport(s, this.ViewportForSurface(s).Add(image.Pt(int(delta.X), int(delta.Y))))
+}
+
+func (this *manager) SetChromaKey(s gopi.Surface, key ChromaKey) error {
+	this.log.Debug2("<graphics.surfacemanager>SetChromaKey{ surface=%v key=%v }", s, key)
+
+	// If no update, then return out of order error
+	this.Lock()
+	defer this.Unlock()
+	if this.update == 0 {
+		return gopi.ErrOutOfOrder
+	}
+
+	if surface_, ok := s.(*surface); ok == false {
+		return gopi.ErrBadParameter
+	} else if key.is_valid() == false {
+		return gopi.ErrBadParameter
+	} else {
+		return this.set_chroma_key(surface_, key)
+	}
+}
+
+func (this *manager) SetSurfaceBitmap(s gopi.Surface, b gopi.Bitmap) error {
+	this.log.Debug2("<graphics.surfacemanager>SetSurfaceBitmap{ surface=%v bitmap=%v }", s, b)
+
+	// If no update, then return out of order error
+	this.Lock()
+	defer this.Unlock()
+	if this.update == 0 {
+		return gopi.ErrOutOfOrder
+	}
+
+	if surface_, ok := s.(*surface); ok == false || surface_.native == nil || surface_.bitmap == nil {
+		return gopi.ErrBadParameter
+	} else if bitmap_, ok := b.(*bitmap); ok == false {
+		return gopi.ErrBadParameter
+	} else if bitmap_.Type() != surface_.flags.Config() {
+		// Pixel format is not compatible
+		return gopi.ErrBadParameter
+	} else if native := surface_.native; uint32(native.src_origin.X)+native.src_size.W > bitmap_.size.W || uint32(native.src_origin.Y)+native.src_size.H > bitmap_.size.H {
+		// Bitmap does not contain the part which is drawn
+		return gopi.ErrBadParameter
+	} else if clamp, err := rpi_dx_clamp(surface_.key); err != nil {
+		return err
+	} else if err := this.replace_element(surface_, bitmap_, clamp); err != nil {
+		return err
+	} else {
+		surface_.set_bitmap(bitmap_)
+		return nil
+	}
+}
+
+////////////////////////////////////////////////////////////////////////////////
+// UNIMPLEMENTED
+
 func (this *manager) SetBitmap(gopi.Bitmap) error {
 	return gopi.ErrNotImplemented
 }
@@ -0,0 +1,858 @@
+// +build !rpi
+
+/*
+  Go Language Raspberry Pi Interface
+  (c) Copyright David Thorpe 2016-2019
+  All Rights Reserved
+
+  Documentation http://djthorpe.github.io/gopi/
+  For Licensing and Usage information, please see LICENSE.md
+*/
+
+package surface
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+	"sort"
+	"sync"
+	"time"
+
+	// Frameworks
+	gopi "github.com/djthorpe/gopi"
+)
+
+////////////////////////////////////////////////////////////////////////////////
+// TYPES
+
+type SurfaceManager struct {
+	Display gopi.Display
+
+	// Rotation and flipping applied to every surface and to
+	// snapshots, for displays which are not mounted upright
+	Transform Transform
+
+	// Refresh rate of the simulated display in Hz, which paces frame
+	// callbacks, or zero for the default
+	RefreshRate uint
+}
+
+type manager struct {
+	log         gopi.Logger
+	display     gopi.Display
+	surfaces    []*surface
+	bitmaps     []*bitmap
+	update      bool
+	framebuffer *image.RGBA
+	transform   Transform
+	fades       fades
+	frames      frames
+
+	// Serializes the start and end of updates between Do and frames
+	updating sync.Mutex
+
+	// Simulated display refresh
+	refresh time.Duration
+	epoch   time.Time
+
+	sync.Mutex
+}
+
+type surface struct {
+	log       gopi.Logger
+	flags     gopi.SurfaceFlags
+	opacity   float32
+	layer     uint16
+	transform Transform
+	key       ChromaKey
+	native    *nativesurface
+	bitmap    gopi.Bitmap
+	owned     gopi.Bitmap
+}
+
+type bitmap struct {
+	log             gopi.Logger
+	flags           gopi.SurfaceFlags
+	size            sw_size
+	data            []byte
+	stride          uint32
+	bytes_per_pixel uint32
+	ref             uint
+	sync.Mutex
+}
+
+type nativesurface struct {
+	size       sw_size
+	origin     sw_point
+	src_size   sw_size
+	src_origin sw_point
+}
+
+type sw_size struct {
+	W, H uint32
+}
+
+type sw_point struct {
+	X, Y int32
+}
+
+////////////////////////////////////////////////////////////////////////////////
+// OPEN AND CLOSE
+
+func (config SurfaceManager) Open(log gopi.Logger) (gopi.Driver, error) {
+	log.Debug("<graphics.surfacemanager.Open>{ display=%v transform=%v }", config.Display, config.Transform)
+
+	this := new(manager)
+	this.log = log
+
+	// Check display
+	this.display = config.Display
+	if this.display == nil {
+		return nil, gopi.ErrBadParameter
+	}
+
+	// Check transform
+	if this.transform = config.Transform; this.transform > SURFACE_TRANSFORM_MAX {
+		return nil, gopi.ErrBadParameter
+	}
+
+	// Set the refresh rate of the simulated display
+	if config.RefreshRate == 0 {
+		config.RefreshRate = REFRESH_RATE_DEFAULT
+	}
+	this.refresh = time.Second / time.Duration(config.RefreshRate)
+	this.epoch = time.Now()
+
+	// Create the offscreen framebuffer which surfaces are composited into
+	if w, h := this.display.Size(); w == 0 || h == 0 {
+		return nil, gopi.ErrBadParameter
+	} else {
+		this.framebuffer = image.NewRGBA(image.Rect(0, 0, int(w), int(h)))
+	}
+
+	// Create surface array
+	this.surfaces = make([]*surface, 0)
+	this.bitmaps = make([]*bitmap, 0)
+
+	return this, nil
+}
+
+func (this *manager) Close() error {
+	this.log.Debug("<graphics.surfacemanager.Close>{ display=%v }", this.display)
+
+	// Check framebuffer is already released
+	if this.framebuffer == nil {
+		return nil
+	}
+
+	// Cancel fades and frame callbacks
+	this.stop_fades()
+	this.stop_frames()
+
+	// Free Surfaces, which are removed from the list as they are destroyed
+	if err := this.Do(func(gopi.SurfaceManager) error {
+		for _, surface := range append([]*surface{}, this.surfaces...) {
+			if err := this.DestroySurface(surface); err != nil {
+				return err
+			}
+		}
+		return nil
+	}); err != nil {
+		return err
+	}
+
+	// Free Bitmaps
+	for _, bitmap := range append([]*bitmap{}, this.bitmaps...) {
+		if err := this.DestroyBitmap(bitmap); err != nil {
+			return err
+		}
+	}
+
+	// Free resources, where updates which start or end after this
+	// return an error
+	this.Lock()
+	defer this.Unlock()
+	this.surfaces = nil
+	this.bitmaps = nil
+	this.display = nil
+	this.framebuffer = nil
+
+	// Return success
+	return nil
+}
+
+////////////////////////////////////////////////////////////////////////////////
+// INTERFACE
+
+func (this *manager) Display() gopi.Display {
+	return this.display
+}
+
+func (this *manager) Name() string {
+	if this.framebuffer == nil {
+		return ""
+	} else {
+		return "software"
+	}
+}
+
+func (this *manager) Extensions() []string {
+	return nil
+}
+
+func (this *manager) Types() []gopi.SurfaceFlags {
+	if this.framebuffer == nil {
+		return nil
+	}
+	// only bitmaps are supported
+	return []gopi.SurfaceFlags{gopi.SURFACE_FLAG_BITMAP}
+}
+
+////////////////////////////////////////////////////////////////////////////////
+// STRINGIFY
+
+func (this *manager) String() string {
+	if this.display == nil {
+		return "<graphics.surfacemanager>{ nil }"
+	} else {
+		return fmt.Sprintf("<graphics.surfacemanager>{ display=%v name=%v types=%v }", this.display, this.Name(), this.Types())
+	}
+}
+
+////////////////////////////////////////////////////////////////////////////////
+// SURFACES
+
+func (this *manager) CreateSurface(flags gopi.SurfaceFlags, opacity float32, layer uint16, origin gopi.Point, size gopi.Size) (gopi.Surface, error) {
+	this.log.Debug2("<graphics.surfacemanager>CreateSurface{ flags=%v opacity=%v layer=%v origin=%v size=%v }", flags, opacity, layer, origin, size)
+
+	// Only bitmap surfaces can be rendered in software
+	if flags.Type() != gopi.SURFACE_FLAG_BITMAP {
+		return nil, gopi.ErrNotImplemented
+	} else if bitmap, err := this.CreateBitmap(flags, size); err != nil {
+		return nil, err
+	} else if surface, err := this.CreateSurfaceWithBitmap(bitmap, flags, opacity, layer, origin, size); err != nil {
+		this.DestroyBitmap(bitmap)
+		return nil, err
+	} else {
+		// The bitmap is destroyed with the surface
+		this.set_owned(surface, bitmap)
+		return surface, nil
+	}
+}
+
+func (this *manager) CreateSurfaceWithBitmap(bitmap gopi.Bitmap, flags gopi.SurfaceFlags, opacity float32, layer uint16, origin gopi.Point, size gopi.Size) (gopi.Surface, error) {
+	return this.CreateSurfaceWithTransform(bitmap, flags, opacity, layer, origin, size, SURFACE_TRANSFORM_NONE)
+}
+
+func (this *manager) CreateSurfaceWithTransform(bitmap gopi.Bitmap, flags gopi.SurfaceFlags, opacity float32, layer uint16, origin gopi.Point, size gopi.Size, transform Transform) (gopi.Surface, error) {
+	if bitmap == nil {
+		return nil, gopi.ErrBadParameter
+	}
+	flags = gopi.SURFACE_FLAG_BITMAP | bitmap.Type() | flags.Mod()
+	this.log.Debug2("<graphics.surfacemanager>CreateSurfaceWithTransform{ bitmap=%v flags=%v opacity=%v layer=%v origin=%v size=%v transform=%v }", bitmap, flags, opacity, layer, origin, size, transform)
+	if opacity < 0.0 || opacity > 1.0 {
+		return nil, gopi.ErrBadParameter
+	} else if layer_is_valid(layer) == false {
+		return nil, gopi.ErrBadParameter
+	} else if transform > SURFACE_TRANSFORM_MAX {
+		return nil, gopi.ErrBadParameter
+	} else if size = size_from_bitmap(bitmap, size, transform); size == gopi.ZeroSize {
+		return nil, gopi.ErrBadParameter
+	} else if native_surface, err := this.CreateNativeSurface(bitmap, flags, opacity, layer, origin, size); err != nil {
+		return nil, err
+	} else {
+		// Return the surface
+		s := &surface{
+			log:       this.log,
+			flags:     flags,
+			opacity:   opacity,
+			layer:     layer,
+			transform: transform,
+			native:    native_surface,
+		}
+		s.set_bitmap(bitmap)
+		this.add_surface(s)
+		return s, nil
+	}
+}
+
+func (this *manager) DestroySurface(s gopi.Surface) error {
+	this.log.Debug2("<graphics.surfacemanager>DestroySurface{ surface=%v }", s)
+
+	if surface_, ok := s.(*surface); ok == false {
+		return gopi.ErrBadParameter
+	} else {
+		if surface_.native != nil {
+			if err := this.DestroyNativeSurface(surface_.native); err != nil {
+				return err
+			} else {
+				surface_.native = nil
+			}
+		}
+		// Release the bitmap, and destroy the bitmap created with the surface
+		surface_.set_bitmap(nil)
+		if err := this.destroy_owned(surface_); err != nil {
+			return err
+		}
+		this.remove_surface(surface_)
+		this.remove_fade(surface_)
+	}
+
+	// Return success
+	return nil
+}
+
+func (this *manager) CreateNativeSurface(b gopi.Bitmap, flags gopi.SurfaceFlags, opacity float32, layer uint16, origin gopi.Point, size gopi.Size) (*nativesurface, error) {
+	this.log.Debug2("<graphics.surfacemanager>CreateNativeSurface{ bitmap=%v flags=%v opacity=%v layer=%v origin=%v size=%v }", b, flags, opacity, layer, origin, size)
+
+	// If no update, then return out of order error
+	this.Lock()
+	defer this.Unlock()
+	if this.update == false {
+		return nil, gopi.ErrOutOfOrder
+	}
+
+	// Check size
+	dest_size := sw_size{uint32(size.W), uint32(size.H)}
+	dest_origin := sw_point{int32(origin.X), int32(origin.Y)}
+	if dest_size.W == 0 || dest_size.H == 0 {
+		return nil, gopi.ErrBadParameter
+	} else if dest_size.W > 0xFFFF || dest_size.H > 0xFFFF {
+		return nil, gopi.ErrBadParameter
+	}
+
+	// Check source bitmap, which is scaled to the size of the surface
+	if bitmap_, ok := b.(*bitmap); ok == false {
+		return nil, gopi.ErrBadParameter
+	} else {
+		// Return the native surface
+		return &nativesurface{dest_size, dest_origin, bitmap_.size, sw_point{}}, nil
+	}
+}
+
+func (this *manager) DestroyNativeSurface(native *nativesurface) error {
+	this.log.Debug2("<graphics.surfacemanager>DestroyNativeSurface{ size=%v origin=%v }", native.size, native.origin)
+
+	// If no update, then return out of order error
+	this.Lock()
+	defer this.Unlock()
+	if this.update == false {
+		return gopi.ErrOutOfOrder
+	}
+
+	// Success
+	return nil
+}
+
+////////////////////////////////////////////////////////////////////////////////
+// BITMAPS
+
+func (this *manager) CreateBitmap(flags gopi.SurfaceFlags, size gopi.Size) (gopi.Bitmap, error) {
+	this.log.Debug2("<graphics.surfacemanager>CreateBitmap{ flags=%v size=%v }", flags, size)
+
+	// Check parameters
+	if flags.Type() != gopi.SURFACE_FLAG_BITMAP {
+		return nil, gopi.ErrBadParameter
+	} else if size.W <= 0.0 || size.H <= 0.0 {
+		return nil, gopi.ErrBadParameter
+	}
+
+	// Create bitmap
+	b := &bitmap{
+		log:   this.log,
+		size:  sw_size{uint32(size.W), uint32(size.H)},
+		flags: gopi.SURFACE_FLAG_BITMAP | flags.Config(),
+	}
+	switch flags.Config() {
+	case gopi.SURFACE_FLAG_RGBA32:
+		b.bytes_per_pixel = 4
+	case gopi.SURFACE_FLAG_RGB888:
+		b.bytes_per_pixel = 3
+	case gopi.SURFACE_FLAG_RGB565:
+		b.bytes_per_pixel = 2
+	default:
+		return nil, gopi.ErrNotImplemented
+	}
+
+	// Allocate pixel data, with rows aligned the same way as on the GPU
+	b.stride = align_up(b.size.W, 16) * b.bytes_per_pixel
+	b.data = make([]byte, b.stride*b.size.H)
+	this.add_bitmap(b)
+	return b, nil
+}
+
+func (this *manager) DestroyBitmap(b gopi.Bitmap) error {
+	this.log.Debug2("<graphics.surfacemanager>DestroyBitmap{ bitmap=%v }", b)
+
+	if bitmap_, ok := b.(*bitmap); ok == false {
+		return gopi.ErrBadParameter
+	} else if bitmap_.in_use() {
+		return ErrBitmapInUse
+	} else {
+		bitmap_.Lock()
+		bitmap_.data = nil
+		bitmap_.Unlock()
+		this.remove_bitmap(bitmap_)
+	}
+
+	// Success
+	return nil
+}
+
+func (this *manager) CreateSnapshot(flags gopi.SurfaceFlags) (gopi.Bitmap, error) {
+	flags = gopi.SURFACE_FLAG_BITMAP | flags.Config() | flags.Mod()
+	display_size := this.display_size()
+	size := gopi.Size{float32(display_size.X), float32(display_size.Y)}
+
+	this.log.Debug2("<graphics.surfacemanager>CreateSnapshot{ flags=%v size=%v }", flags, size)
+
+	if b, err := this.CreateBitmap(flags, size); err != nil {
+		return nil, err
+	} else if bitmap_, ok := b.(*bitmap); ok == false {
+		return nil, gopi.ErrAppError
+	} else {
+		// Copy the framebuffer, reversing the display transform
+		this.Lock()
+		defer this.Unlock()
+		for y := uint32(0); y < bitmap_.size.H; y++ {
+			for x := uint32(0); x < bitmap_.size.W; x++ {
+				pixel := transform_pixel(this.transform, display_size, image.Pt(int(x), int(y)))
+				bitmap_.set(x, y, this.framebuffer.RGBAAt(pixel.X, pixel.Y))
+			}
+		}
+		return bitmap_, nil
+	}
+}
+
+////////////////////////////////////////////////////////////////////////////////
+// PRIVATE METHODS
+
+func align_up(value, alignment uint32) uint32 {
+	return (value + alignment - 1) / alignment * alignment
+}
+
+func opacity_from_float(opacity float32) uint8 {
+	if opacity < 0.0 {
+		opacity = 0.0
+	} else if opacity > 1.0 {
+		opacity = 1.0
+	}
+	// Opacity is between 0 (fully transparent) and 255 (fully opaque)
+	return uint8(opacity * float32(0xFF))
+}
+
+// set_viewport sets the source rectangle for a surface
+func (this *manager) set_viewport(s gopi.Surface, rect image.Rectangle) error {
+	if surface_, ok := s.(*surface); ok == false || surface_.native == nil {
+		return gopi.ErrBadParameter
+	} else if surface_.bitmap == nil || viewport_is_valid(rect, surface_.bitmap) == false {
+		return gopi.ErrBadParameter
+	} else {
+		surface_.native.src_origin = sw_point{int32(rect.Min.X), int32(rect.Min.Y)}
+		surface_.native.src_size = sw_size{uint32(rect.Dx()), uint32(rect.Dy())}
+		return nil
+	}
+}
+
+// set_chroma_key sets the key for a surface, which is applied when
+// the surface is composited
+func (this *manager) set_chroma_key(s *surface, key ChromaKey) error {
+	s.key = key
+	return nil
+}
+
+func size_from_bitmap(bitmap gopi.Bitmap, size gopi.Size, transform Transform) gopi.Size {
+	if size != gopi.ZeroSize {
+		return size
+	} else if size := bitmap.Size(); transform.Swaps() {
+		return gopi.Size{size.H, size.W}
+	} else {
+		return size
+	}
+}
+
+////////////////////////////////////////////////////////////////////////////////
+// COMPOSITING
+
+// composite renders all surfaces into the framebuffer, in layer order,
+// and should be called with the manager locked
+func (this *manager) composite() {
+	// Clear the framebuffer to black
+	bounds := this.framebuffer.Bounds()
+	for i := range this.framebuffer.Pix {
+		if i%4 == 3 {
+			this.framebuffer.Pix[i] = 0xFF
+		} else {
+			this.framebuffer.Pix[i] = 0x00
+		}
+	}
+
+	// Order surfaces by layer, retaining creation order within a layer
+	surfaces := make([]*surface, 0, len(this.surfaces))
+	for _, surface := range this.surfaces {
+		if surface.native != nil && surface.bitmap != nil {
+			surfaces = append(surfaces, surface)
+		}
+	}
+	sort.SliceStable(surfaces, func(i, j int) bool {
+		return surfaces[i].layer < surfaces[j].layer
+	})
+
+	// Draw each surface
+	for _, surface := range surfaces {
+		this.composite_surface(surface, bounds)
+	}
+}
+
+func (this *manager) composite_surface(s *surface, bounds image.Rectangle) {
+	bitmap_, ok := s.bitmap.(*bitmap)
+	if ok == false {
+		return
+	}
+
+	bitmap_.Lock()
+	defer bitmap_.Unlock()
+	if bitmap_.data == nil {
+		return
+	}
+
+	// Calculate the intersection between the surface and the framebuffer,
+	// where the display transform is applied to the surface
+	native := s.native
+	frame := image.Rect(int(native.origin.X), int(native.origin.Y), int(native.origin.X)+int(native.size.W), int(native.origin.Y)+int(native.size.H))
+	frame = transform_rect(this.transform, this.display_size(), frame)
+	dest := frame.Intersect(bounds)
+	if dest.Empty() {
+		return
+	}
+
+	// Blend pixels, reversing the surface and display transforms and
+	// scaling the source rectangle to the size of the surface
+	inverse := s.transform.Then(this.transform).Inverse()
+	content := transform_size(inverse, frame.Size())
+	opacity := uint32(opacity_from_float(s.opacity))
+	alpha_from_source := s.flags.Mod()&gopi.SURFACE_FLAG_ALPHA_FROM_SOURCE != 0
+	for y := dest.Min.Y; y < dest.Max.Y; y++ {
+		for x := dest.Min.X; x < dest.Max.X; x++ {
+			pixel := transform_pixel(inverse, frame.Size(), image.Pt(x-frame.Min.X, y-frame.Min.Y))
+			sx := uint32(native.src_origin.X) + uint32(pixel.X)*native.src_size.W/uint32(content.X)
+			sy := uint32(native.src_origin.Y) + uint32(pixel.Y)*native.src_size.H/uint32(content.Y)
+			src := bitmap_.at(sx, sy)
+			if s.key.matches(src) {
+				continue
+			}
+			alpha := opacity
+			if alpha_from_source {
+				alpha = uint32(src.A) * opacity / 0xFF
+			}
+			this.framebuffer.SetRGBA(x, y, blend(this.framebuffer.RGBAAt(x, y), src, alpha))
+		}
+	}
+}
+
+// blend returns the source pixel composited over an opaque
+// destination pixel with alpha between 0 and 255
+func blend(dst color.RGBA, src color.NRGBA, alpha uint32) color.RGBA {
+	return color.RGBA{
+		uint8((uint32(src.R)*alpha + uint32(dst.R)*(0xFF-alpha)) / 0xFF),
+		uint8((uint32(src.G)*alpha + uint32(dst.G)*(0xFF-alpha)) / 0xFF),
+		uint8((uint32(src.B)*alpha + uint32(dst.B)*(0xFF-alpha)) / 0xFF),
+		0xFF,
+	}
+}
+
+////////////////////////////////////////////////////////////////////////////////
+// UPDATES
+
+func (this *manager) Do(callback gopi.SurfaceManagerCallback) error {
+	if this.framebuffer == nil {
+		return gopi.ErrBadParameter
+	}
+	_, err := this.do(callback, false)
+	return err
+}
+
+func (this *manager) DoAsync(callback gopi.SurfaceManagerCallback) (<-chan error, error) {
+	if this.framebuffer == nil {
+		return nil, gopi.ErrBadParameter
+	}
+	return this.do(callback, true)
+}
+
+func (this *manager) in_update() bool {
+	this.Lock()
+	defer this.Unlock()
+	return this.update
+}
+
+// begin_update starts an update, unless the manager has been closed
+func (this *manager) begin_update() error {
+	this.Lock()
+	defer this.Unlock()
+	if this.framebuffer == nil {
+		return gopi.ErrOutOfOrder
+	}
+	this.update = true
+	return nil
+}
+
+// end_update composites the surfaces into the framebuffer, unless the
+// manager was closed during the update
+func (this *manager) end_update() error {
+	this.Lock()
+	defer this.Unlock()
+	this.update = false
+	if this.framebuffer == nil {
+		return gopi.ErrOutOfOrder
+	}
+	this.composite()
+	return nil
+}
+
+// wait_vsync waits for the next refresh of the simulated display and
+// returns the time of the refresh, or returns false if stopped
+func (this *manager) wait_vsync(stop <-chan struct{}) (time.Time, bool) {
+	now := time.Now()
+	next := now.Add(this.refresh - now.Sub(this.epoch)%this.refresh)
+	timer := time.NewTimer(next.Sub(now))
+	defer timer.Stop()
+	select {
+	case <-stop:
+		return time.Time{}, false
+	case <-timer.C:
+		return next, true
+	}
+}
+
+////////////////////////////////////////////////////////////////////////////////
+// MOVE SURFACES
+
+func (this *manager) SetOrigin(s gopi.Surface, origin gopi.Point) error {
+	this.log.Debug2("<graphics.surfacemanager>SetOrigin{ surface=%v origin=%v }", s, origin)
+
+	// If no update, then return out of order error
+	this.Lock()
+	defer this.Unlock()
+	if this.update == false {
+		return gopi.ErrOutOfOrder
+	}
+
+	if surface_, ok := s.(*surface); ok == false {
+		return gopi.ErrBadParameter
+	} else {
+		surface_.native.origin = sw_point{int32(origin.X), int32(origin.Y)}
+		return nil
+	}
+}
+
+func (this *manager) MoveOriginBy(s gopi.Surface, increment gopi.Point) error {
+	this.log.Debug2("<graphics.surfacemanager>MoveOriginBy{ surface=%v increment=%v }", s, increment)
+
+	// If no update, then return out of order error
+	this.Lock()
+	defer this.Unlock()
+	if this.update == false {
+		return gopi.ErrOutOfOrder
+	}
+
+	if surface_, ok := s.(*surface); ok == false {
+		return gopi.ErrBadParameter
+	} else {
+		surface_.native.origin.X += int32(increment.X)
+		surface_.native.origin.Y += int32(increment.Y)
+		return nil
+	}
+}
+
+func (this *manager) SetLayer(s gopi.Surface, layer uint16) error {
+	this.log.Debug2("<graphics.surfacemanager>SetLayer{ surface=%v layer=%v }", s, layer)
+
+	// If no update, then return out of order error
+	this.Lock()
+	defer this.Unlock()
+	if this.update == false {
+		return gopi.ErrOutOfOrder
+	}
+
+	if surface_, ok := s.(*surface); ok == false {
+		return gopi.ErrBadParameter
+	} else if s.Layer() == gopi.SURFACE_LAYER_BACKGROUND || s.Layer() == gopi.SURFACE_LAYER_CURSOR {
+		// Can't change background or cursor layers
+		return gopi.ErrBadParameter
+	} else if layer < gopi.SURFACE_LAYER_DEFAULT || layer > gopi.SURFACE_LAYER_MAX {
+		// Invalid layer change
+		return gopi.ErrBadParameter
+	} else {
+		surface_.layer = layer
+		return nil
+	}
+}
+
+func (this *manager) SetOpacity(s gopi.Surface, opacity float32) error {
+	this.log.Debug2("<graphics.surfacemanager>SetOpacity{ surface=%v opacity=%v }", s, opacity)
+
+	// If no update, then return out of order error
+	this.Lock()
+	defer this.Unlock()
+	if this.update == false {
+		return gopi.ErrOutOfOrder
+	}
+
+	if surface_, ok := s.(*surface); ok == false {
+		return gopi.ErrBadParameter
+	} else if opacity < 0.0 || opacity > 1.0 {
+		return gopi.ErrBadParameter
+	} else {
+		surface_.opacity = opacity
+		return nil
+	}
+}
+
+func (this *manager) SetSize(s gopi.Surface, size gopi.Size) error {
+	return this.SetSizeWithMode(s, size, SURFACE_SIZE_SCALE)
+}
+
+func (this *manager) SetSizeWithMode(s gopi.Surface, size gopi.Size, mode SizeMode) error {
+	this.log.Debug2("<graphics.surfacemanager>SetSizeWithMode{ surface=%v size=%v mode=%v }", s, size, mode)
+
+	// If no update, then return out of order error
+	this.Lock()
+	defer this.Unlock()
+	if this.update == false {
+		return gopi.ErrOutOfOrder
+	}
+
+	// Check size
+	dest_size := sw_size{uint32(size.W), uint32(size.H)}
+	if dest_size.W == 0 || dest_size.H == 0 {
+		return gopi.ErrBadParameter
+	} else if dest_size.W > 0xFFFF || dest_size.H > 0xFFFF {
+		return gopi.ErrBadParameter
+	}
+
+	if surface_, ok := s.(*surface); ok == false {
+		return gopi.ErrBadParameter
+	} else if bitmap_, ok := surface_.bitmap.(*bitmap); ok == false {
+		return gopi.ErrBadParameter
+	} else {
+		switch mode {
+		case SURFACE_SIZE_SCALE:
+			surface_.native.size = dest_size
+		case SURFACE_SIZE_CLIP:
+			// The source rectangle is the same size as the surface
+			// before the transform is applied
+			origin := surface_.native.src_origin
+			src_size := dest_size
+			if surface_.transform.Swaps() {
+				src_size = sw_size{dest_size.H, dest_size.W}
+			}
+			if uint32(origin.X)+src_size.W > bitmap_.size.W || uint32(origin.Y)+src_size.H > bitmap_.size.H {
+				return gopi.ErrBadParameter
+			}
+			surface_.native.size = dest_size
+			surface_.native.src_size = src_size
+		default:
+			return gopi.ErrBadParameter
+		}
+		return nil
+	}
+}
+
+func (this *manager) SetTransform(s gopi.Surface, transform Transform) error {
+	this.log.Debug2("<graphics.surfacemanager>SetTransform{ surface=%v transform=%v }", s, transform)
+
+	// If no update, then return out of order error
+	this.Lock()
+	defer this.Unlock()
+	if this.update == false {
+		return gopi.ErrOutOfOrder
+	}
+
+	if surface_, ok := s.(*surface); ok == false {
+		return gopi.ErrBadParameter
+	} else if transform > SURFACE_TRANSFORM_MAX {
+		return gopi.ErrBadParameter
+	} else {
+		surface_.transform = transform
+		return nil
+	}
+}
+
+func (this *manager) SetViewport(s gopi.Surface, rect image.Rectangle) error {
+	this.log.Debug2("<graphics.surfacemanager>SetViewport{ surface=%v rect=%v }", s, rect)
+
+	// If no update, then return out of order error
+	this.Lock()
+	defer this.Unlock()
+	if this.update == false {
+		return gopi.ErrOutOfOrder
+	}
+
+	return this.set_viewport(s, rect)
+}
+
+func (this *manager) MoveViewportBy(s gopi.Surface, delta gopi.Point) error {
+	this.log.Debug2("<graphics.surfacemanager>MoveViewportBy{ surface=%v delta=%v }", s, delta)
+
+	// If no update, then return out of order error
+	this.Lock()
+	defer this.Unlock()
+	if this.update == false {
+		return gopi.ErrOutOfOrder
+	}
+
+	return this.set_viewport(s, this.ViewportForSurface(s).Add(image.Pt(int(delta.X), int(delta.Y))))
+}
+
+func (this *manager) SetChromaKey(s gopi.Surface, key ChromaKey) error {
+	this.log.Debug2("<graphics.surfacemanager>SetChromaKey{ surface=%v key=%v }", s, key)
+
+	// If no update, then return out of order error
+	this.Lock()
+	defer this.Unlock()
+	if this.update == false {
+		return gopi.ErrOutOfOrder
+	}
+
+	if surface_, ok := s.(*surface); ok == false {
+		return gopi.ErrBadParameter
+	} else if key.is_valid() == false {
+		return gopi.ErrBadParameter
+	} else {
+		return this.set_chroma_key(surface_, key)
+	}
+}
+
+func (this *manager) SetSurfaceBitmap(s gopi.Surface, b gopi.Bitmap) error {
+	this.log.Debug2("<graphics.surfacemanager>SetSurfaceBitmap{ surface=%v bitmap=%v }", s, b)
+
+	// If no update, then return out of order error
+	this.Lock()
+	defer this.Unlock()
+	if this.update == false {
+		return gopi.ErrOutOfOrder
+	}
+
+	if surface_, ok := s.(*surface); ok == false || surface_.native == nil {
+		return gopi.ErrBadParameter
+	} else if bitmap_, ok := b.(*bitmap); ok == false {
+		return gopi.ErrBadParameter
+	} else if bitmap_.Type() != surface_.flags.Config() {
+		// Pixel format is not compatible
+		return gopi.ErrBadParameter
+	} else if native := surface_.native; uint32(native.src_origin.X)+native.src_size.W > bitmap_.size.W || uint32(native.src_origin.Y)+native.src_size.H > bitmap_.size.H {
+		// Bitmap does not contain the part which is drawn
+		return gopi.ErrBadParameter
+	} else {
+		surface_.set_bitmap(bitmap_)
+		return nil
+	}
+}
+
+////////////////////////////////////////////////////////////////////////////////
+// UNIMPLEMENTED
+
+func (this *manager) SetBitmap(gopi.Bitmap) error {
+	return gopi.ErrNotImplemented
+}
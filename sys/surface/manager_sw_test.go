@@ -0,0 +1,907 @@
+// +build !rpi
+
+/*
+  Go Language Raspberry Pi Interface
+  (c) Copyright David Thorpe 2016-2019
+  All Rights Reserved
+
+  Documentation http://djthorpe.github.io/gopi/
+  For Licensing and Usage information, please see LICENSE.md
+*/
+
+package surface_test
+
+import (
+	"flag"
+	"fmt"
+	"image"
+	"image/color"
+	"image/png"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	// Frameworks
+	gopi "github.com/djthorpe/gopi"
+	display "github.com/djthorpe/gopi-graphics/sys/display"
+	logger "github.com/djthorpe/gopi-graphics/sys/internal/logger"
+	surface "github.com/djthorpe/gopi-graphics/sys/surface"
+)
+
+////////////////////////////////////////////////////////////////////////////////
+// TYPES
+
+// golden is a test which runs each step in a separate update and
+// compares the composited display with testdata/<name>.png
+type golden struct {
+	name  string
+	steps []gopi.SurfaceManagerCallback
+}
+
+////////////////////////////////////////////////////////////////////////////////
+// GLOBAL VARIABLES
+
+var (
+	flagUpdate    = flag.Bool("golden.update", false, "Write golden images from the test results")
+	flagDiff      = flag.String("golden.diff", filepath.Join(os.TempDir(), "golden"), "Folder for images which differ from golden images")
+	flagTolerance = flag.Uint("golden.tolerance", 2, "Maximum difference for each color component")
+)
+
+const (
+	GOLDEN_WIDTH  = 64
+	GOLDEN_HEIGHT = 48
+)
+
+var (
+	red   = gopi.Color{1, 0, 0, 1}
+	green = gopi.Color{0, 1, 0, 1}
+	blue  = gopi.Color{0, 0, 1, 1}
+	white = gopi.Color{1, 1, 1, 1}
+)
+
+////////////////////////////////////////////////////////////////////////////////
+// TESTS
+
+func Test_FillRectToColor_000(t *testing.T) {
+	bitmap := new(gopi.Bitmap)
+	run_golden(t, golden{"fill_rect_clipping", []gopi.SurfaceManagerCallback{
+		func(gfx gopi.SurfaceManager) error {
+			return create_bitmap_surface(gfx, bitmap, gopi.SURFACE_FLAG_RGB888, gopi.Size{16, 16}, 0, 1.0, gopi.SURFACE_LAYER_DEFAULT, gopi.Point{8, 8}, red)
+		},
+		func(gfx gopi.SurfaceManager) error {
+			// Clipped at the top left, bottom right and entirely outside the bitmap
+			if err := (*bitmap).FillRectToColor(gopi.Point{-4, -4}, gopi.Size{10, 10}, blue); err != nil {
+				return err
+			} else if err := (*bitmap).FillRectToColor(gopi.Point{12, 12}, gopi.Size{10, 10}, green); err != nil {
+				return err
+			} else if err := (*bitmap).FillRectToColor(gopi.Point{20, 0}, gopi.Size{4, 4}, white); err != nil {
+				return err
+			} else {
+				return (*bitmap).FillRectToColor(gopi.Point{4, 8}, gopi.Size{1, 1}, white)
+			}
+		},
+	}})
+}
+
+func Test_MoveOriginBy_000(t *testing.T) {
+	surface1, surface2 := new(gopi.Surface), new(gopi.Surface)
+	run_golden(t, golden{"move_origin", []gopi.SurfaceManagerCallback{
+		func(gfx gopi.SurfaceManager) error {
+			if err := create_surface(gfx, surface1, gopi.SURFACE_FLAG_RGB565, gopi.Size{10, 10}, 0, 1.0, gopi.SURFACE_LAYER_DEFAULT, gopi.ZeroPoint, green); err != nil {
+				return err
+			} else {
+				return create_surface(gfx, surface2, gopi.SURFACE_FLAG_RGB888, gopi.Size{12, 8}, 0, 1.0, gopi.SURFACE_LAYER_DEFAULT, gopi.Point{40, 30}, blue)
+			}
+		},
+		func(gfx gopi.SurfaceManager) error {
+			if err := gfx.MoveOriginBy(*surface1, gopi.Point{10, 5}); err != nil {
+				return err
+			} else {
+				return gfx.MoveOriginBy(*surface2, gopi.Point{20, 10})
+			}
+		},
+		func(gfx gopi.SurfaceManager) error {
+			// The second surface is partly off the display
+			if err := gfx.MoveOriginBy(*surface1, gopi.Point{10, 5}); err != nil {
+				return err
+			} else {
+				return gfx.MoveOriginBy(*surface2, gopi.Point{-70, -5})
+			}
+		},
+	}})
+}
+
+func Test_SetLayer_000(t *testing.T) {
+	surface1, surface2, surface3 := new(gopi.Surface), new(gopi.Surface), new(gopi.Surface)
+	run_golden(t, golden{"set_layer", []gopi.SurfaceManagerCallback{
+		func(gfx gopi.SurfaceManager) error {
+			if err := create_surface(gfx, surface1, gopi.SURFACE_FLAG_RGB888, gopi.Size{20, 20}, 0, 1.0, 3, gopi.Point{4, 4}, red); err != nil {
+				return err
+			} else if err := create_surface(gfx, surface2, gopi.SURFACE_FLAG_RGB888, gopi.Size{20, 20}, 0, 1.0, 2, gopi.Point{14, 14}, green); err != nil {
+				return err
+			} else {
+				return create_surface(gfx, surface3, gopi.SURFACE_FLAG_RGB888, gopi.Size{20, 20}, 0, 1.0, gopi.SURFACE_LAYER_DEFAULT, gopi.Point{24, 24}, blue)
+			}
+		},
+		func(gfx gopi.SurfaceManager) error {
+			// Move the blue surface to the top and the red surface to the bottom
+			if err := gfx.SetLayer(*surface3, 4); err != nil {
+				return err
+			} else {
+				return gfx.SetLayer(*surface1, gopi.SURFACE_LAYER_DEFAULT)
+			}
+		},
+	}})
+}
+
+func Test_SetOpacity_000(t *testing.T) {
+	surface1, surface2, surface3 := new(gopi.Surface), new(gopi.Surface), new(gopi.Surface)
+	translucent := gopi.Color{1, 1, 1, 0.5}
+	run_golden(t, golden{"set_opacity", []gopi.SurfaceManagerCallback{
+		func(gfx gopi.SurfaceManager) error {
+			// Background, translucent bitmap with alpha from source, and the
+			// same bitmap without alpha from source
+			if err := create_surface(gfx, surface1, gopi.SURFACE_FLAG_RGB888, gopi.Size{GOLDEN_WIDTH, GOLDEN_HEIGHT / 2}, 0, 1.0, gopi.SURFACE_LAYER_DEFAULT, gopi.ZeroPoint, blue); err != nil {
+				return err
+			} else if err := create_surface(gfx, surface2, gopi.SURFACE_FLAG_RGBA32, gopi.Size{16, 40}, gopi.SURFACE_FLAG_ALPHA_FROM_SOURCE, 1.0, 2, gopi.Point{8, 4}, translucent); err != nil {
+				return err
+			} else {
+				return create_surface(gfx, surface3, gopi.SURFACE_FLAG_RGBA32, gopi.Size{16, 40}, 0, 1.0, 2, gopi.Point{40, 4}, translucent)
+			}
+		},
+		func(gfx gopi.SurfaceManager) error {
+			if err := gfx.SetOpacity(*surface3, 0.75); err != nil {
+				return err
+			} else {
+				return gfx.SetOpacity(*surface2, 0.5)
+			}
+		},
+	}})
+}
+
+func Test_Scale_000(t *testing.T) {
+	bitmap, surface1 := new(gopi.Bitmap), new(gopi.Surface)
+	run_golden(t, golden{"scale", []gopi.SurfaceManagerCallback{
+		func(gfx gopi.SurfaceManager) error {
+			// A 2x2 bitmap with a different color in each pixel, scaled up
+			if b, err := gfx.CreateBitmap(gopi.SURFACE_FLAG_BITMAP|gopi.SURFACE_FLAG_RGB888, gopi.Size{2, 2}); err != nil {
+				return err
+			} else if err := b.ClearToColor(red); err != nil {
+				return err
+			} else if err := b.FillRectToColor(gopi.Point{1, 0}, gopi.Size{1, 1}, green); err != nil {
+				return err
+			} else if err := b.FillRectToColor(gopi.Point{0, 1}, gopi.Size{1, 1}, blue); err != nil {
+				return err
+			} else if s, err := gfx.CreateSurfaceWithBitmap(b, 0, 1.0, gopi.SURFACE_LAYER_DEFAULT, gopi.Point{12, 4}, gopi.Size{40, 40}); err != nil {
+				return err
+			} else {
+				*bitmap, *surface1 = b, s
+				return nil
+			}
+		},
+	}})
+}
+
+func Test_SetSize_000(t *testing.T) {
+	surfaces := make([]gopi.Surface, 3)
+	run_golden(t, golden{"set_size", []gopi.SurfaceManagerCallback{
+		func(gfx gopi.SurfaceManager) error {
+			// Surfaces with red bitmaps which have a green square in the top left
+			for i := range surfaces {
+				if b, err := gfx.CreateBitmap(gopi.SURFACE_FLAG_BITMAP|gopi.SURFACE_FLAG_RGB888, gopi.Size{16, 16}); err != nil {
+					return err
+				} else if err := b.ClearToColor(red); err != nil {
+					return err
+				} else if err := b.FillRectToColor(gopi.ZeroPoint, gopi.Size{8, 8}, green); err != nil {
+					return err
+				} else if s, err := gfx.CreateSurfaceWithBitmap(b, 0, 1.0, gopi.SURFACE_LAYER_DEFAULT, gopi.Point{float32(2 + i*20), 2}, gopi.ZeroSize); err != nil {
+					return err
+				} else {
+					surfaces[i] = s
+				}
+			}
+			return nil
+		},
+		func(gfx gopi.SurfaceManager) error {
+			// Scale the first surface, clip the second and scale the
+			// clipped third surface
+			scaler := gfx.(surface.SurfaceScaler)
+			if err := gfx.SetSize(surfaces[0], gopi.Size{16, 32}); err != nil {
+				return err
+			} else if err := scaler.SetSizeWithMode(surfaces[1], gopi.Size{12, 12}, surface.SURFACE_SIZE_CLIP); err != nil {
+				return err
+			} else if err := scaler.SetSizeWithMode(surfaces[1], gopi.Size{20, 20}, surface.SURFACE_SIZE_CLIP); err != gopi.ErrBadParameter {
+				return fmt.Errorf("Expected ErrBadParameter, got %v", err)
+			} else if err := scaler.SetSizeWithMode(surfaces[2], gopi.Size{8, 8}, surface.SURFACE_SIZE_CLIP); err != nil {
+				return err
+			} else if err := scaler.SetSizeWithMode(surfaces[2], gopi.Size{20, 40}, surface.SURFACE_SIZE_SCALE); err != nil {
+				return err
+			} else if size := surfaces[2].Size(); size != (gopi.Size{20, 40}) {
+				return fmt.Errorf("Unexpected size %v", size)
+			} else {
+				return nil
+			}
+		},
+	}})
+}
+
+func Test_SetSurfaceBitmap_000(t *testing.T) {
+	surface1, front, back := new(gopi.Surface), new(gopi.Bitmap), new(gopi.Bitmap)
+	run_golden(t, golden{"set_surface_bitmap", []gopi.SurfaceManagerCallback{
+		func(gfx gopi.SurfaceManager) error {
+			for i, b := range []*gopi.Bitmap{front, back} {
+				if bitmap, err := gfx.CreateBitmap(gopi.SURFACE_FLAG_BITMAP|gopi.SURFACE_FLAG_RGB888, gopi.Size{32, 32}); err != nil {
+					return err
+				} else if err := bitmap.ClearToColor([]gopi.Color{red, green}[i]); err != nil {
+					return err
+				} else {
+					*b = bitmap
+				}
+			}
+			if s, err := gfx.CreateSurfaceWithBitmap(*front, 0, 1.0, gopi.SURFACE_LAYER_DEFAULT, gopi.Point{16, 8}, gopi.ZeroSize); err != nil {
+				return err
+			} else {
+				*surface1 = s
+				return nil
+			}
+		},
+		func(gfx gopi.SurfaceManager) error {
+			// Swap the back bitmap onto the surface, after which the front
+			// bitmap can be destroyed and the back bitmap cannot
+			setter := gfx.(surface.SurfaceBitmapSetter)
+			if err := gfx.DestroyBitmap(*front); err != surface.ErrBitmapInUse {
+				return fmt.Errorf("Expected ErrBitmapInUse, got %v", err)
+			} else if b, err := gfx.CreateBitmap(gopi.SURFACE_FLAG_BITMAP|gopi.SURFACE_FLAG_RGB565, gopi.Size{32, 32}); err != nil {
+				return err
+			} else if err := setter.SetSurfaceBitmap(*surface1, b); err != gopi.ErrBadParameter {
+				return fmt.Errorf("Expected ErrBadParameter, got %v", err)
+			} else if err := setter.SetSurfaceBitmap(*surface1, *back); err != nil {
+				return err
+			} else if err := gfx.DestroyBitmap(*back); err != surface.ErrBitmapInUse {
+				return fmt.Errorf("Expected ErrBitmapInUse, got %v", err)
+			} else {
+				return gfx.DestroyBitmap(*front)
+			}
+		},
+	}})
+}
+
+func Test_Transform_000(t *testing.T) {
+	// Draw a bitmap with a marker in the top left corner with each transform
+	run_golden(t, golden{"transform", []gopi.SurfaceManagerCallback{
+		func(gfx gopi.SurfaceManager) error {
+			return create_transform_surfaces(gfx)
+		},
+	}})
+}
+
+func Test_Transform_001(t *testing.T) {
+	// Rotate the display, so that surfaces are positioned on the rotated
+	// display and the snapshot is upright
+	run_golden_with_transform(t, golden{"transform_display", []gopi.SurfaceManagerCallback{
+		func(gfx gopi.SurfaceManager) error {
+			if transform := gfx.(surface.SurfaceTransformer).DisplayTransform(); transform != surface.SURFACE_TRANSFORM_ROTATE_90 {
+				return fmt.Errorf("Expected SURFACE_TRANSFORM_ROTATE_90, got %v", transform)
+			} else if w, h := gfx.Display().Size(); w != GOLDEN_WIDTH || h != GOLDEN_HEIGHT {
+				return fmt.Errorf("Unexpected display size %vx%v", w, h)
+			} else {
+				return create_transform_surfaces(gfx)
+			}
+		},
+	}}, surface.SURFACE_TRANSFORM_ROTATE_90)
+}
+
+func Test_Transform_002(t *testing.T) {
+	transforms := []surface.Transform{
+		surface.SURFACE_TRANSFORM_NONE,
+		surface.SURFACE_TRANSFORM_ROTATE_90,
+		surface.SURFACE_TRANSFORM_ROTATE_180,
+		surface.SURFACE_TRANSFORM_ROTATE_270,
+		surface.SURFACE_TRANSFORM_FLIP_H,
+		surface.SURFACE_TRANSFORM_FLIP_V,
+		surface.SURFACE_TRANSFORM_FLIP_H | surface.SURFACE_TRANSFORM_ROTATE_90,
+	}
+	for _, a := range transforms {
+		if a.Then(a.Inverse()) != surface.SURFACE_TRANSFORM_NONE {
+			t.Errorf("%v: inverse is %v", a, a.Inverse())
+		}
+		for _, b := range transforms {
+			if a.Then(b).Then(b.Inverse()) != a.Then(surface.SURFACE_TRANSFORM_NONE) {
+				t.Errorf("%v then %v is %v", a, b, a.Then(b))
+			}
+		}
+	}
+	if transform, err := surface.TransformForRotation(270); err != nil || transform != surface.SURFACE_TRANSFORM_ROTATE_270 {
+		t.Error("Unexpected transform", transform, err)
+	} else if _, err := surface.TransformForRotation(45); err != gopi.ErrBadParameter {
+		t.Error("Expected ErrBadParameter, got", err)
+	}
+}
+
+func Test_Viewport_000(t *testing.T) {
+	surface1, surface2 := new(gopi.Surface), new(gopi.Surface)
+	run_golden(t, golden{"viewport", []gopi.SurfaceManagerCallback{
+		func(gfx gopi.SurfaceManager) error {
+			// Create a sprite sheet with a color in each quarter, and draw
+			// the top right quarter unscaled and the top left quarter scaled
+			viewport := gfx.(surface.SurfaceViewport)
+			bitmap, err := gfx.CreateBitmap(gopi.SURFACE_FLAG_BITMAP|gopi.SURFACE_FLAG_RGB888, gopi.Size{32, 32})
+			if err != nil {
+				return err
+			}
+			for i, c := range []gopi.Color{red, green, blue, white} {
+				if err := bitmap.FillRectToColor(gopi.Point{float32(i%2) * 16, float32(i/2) * 16}, gopi.Size{16, 16}, c); err != nil {
+					return err
+				}
+			}
+			if s, err := gfx.CreateSurfaceWithBitmap(bitmap, 0, 1.0, gopi.SURFACE_LAYER_DEFAULT, gopi.Point{4, 4}, gopi.Size{16, 16}); err != nil {
+				return err
+			} else if err := viewport.SetViewport(s, image.Rect(16, 0, 32, 16)); err != nil {
+				return err
+			} else {
+				*surface1 = s
+			}
+			if s, err := gfx.CreateSurfaceWithBitmap(bitmap, 0, 1.0, gopi.SURFACE_LAYER_DEFAULT, gopi.Point{24, 4}, gopi.Size{32, 32}); err != nil {
+				return err
+			} else if err := viewport.SetViewport(s, image.Rect(0, 0, 16, 16)); err != nil {
+				return err
+			} else if err := viewport.SetViewport(s, image.Rect(8, 8, 40, 24)); err != gopi.ErrBadParameter {
+				return fmt.Errorf("Expected ErrBadParameter, got %v", err)
+			} else {
+				*surface2 = s
+			}
+			return nil
+		},
+		func(gfx gopi.SurfaceManager) error {
+			// Pan the second surface to the bottom left quarter, and check
+			// the first surface cannot be panned outside the bitmap
+			viewport := gfx.(surface.SurfaceViewport)
+			if err := viewport.MoveViewportBy(*surface2, gopi.Point{0, 16}); err != nil {
+				return err
+			} else if rect := viewport.ViewportForSurface(*surface2); rect != image.Rect(0, 16, 16, 32) {
+				return fmt.Errorf("Unexpected viewport %v", rect)
+			} else if err := viewport.MoveViewportBy(*surface1, gopi.Point{8, 0}); err != gopi.ErrBadParameter {
+				return fmt.Errorf("Expected ErrBadParameter, got %v", err)
+			} else if rect := viewport.ViewportForSurface(*surface1); rect != image.Rect(16, 0, 32, 16) {
+				return fmt.Errorf("Unexpected viewport %v", rect)
+			} else {
+				return nil
+			}
+		},
+	}})
+}
+
+func Test_ChromaKey_000(t *testing.T) {
+	magenta := gopi.Color{1, 0, 1, 1}
+	surface3 := new(gopi.Surface)
+	run_golden(t, golden{"chroma_key", []gopi.SurfaceManagerCallback{
+		func(gfx gopi.SurfaceManager) error {
+			// Draw bitmaps with a key colored border over a background,
+			// where the border is transparent
+			keyer := gfx.(surface.SurfaceChromaKeyer)
+			if err := create_bitmap_surface(gfx, new(gopi.Bitmap), gopi.SURFACE_FLAG_RGB888, gopi.Size{GOLDEN_WIDTH, GOLDEN_HEIGHT}, 0, 1.0, gopi.SURFACE_LAYER_DEFAULT, gopi.ZeroPoint, blue); err != nil {
+				return err
+			}
+			for i, key := range []surface.ChromaKey{
+				surface.ChromaKeyForColor(magenta, 8),
+				surface.ChromaKey{surface.CHROMA_KEY_YUV, [3]uint8{96, 200, 224}, [3]uint8{116, 224, 255}},
+				surface.ChromaKeyForColor(magenta, 8),
+			} {
+				if bitmap, err := gfx.CreateBitmap(gopi.SURFACE_FLAG_BITMAP|gopi.SURFACE_FLAG_RGB565, gopi.Size{16, 16}); err != nil {
+					return err
+				} else if err := bitmap.ClearToColor(magenta); err != nil {
+					return err
+				} else if err := bitmap.FillRectToColor(gopi.Point{4, 4}, gopi.Size{8, 8}, green); err != nil {
+					return err
+				} else if s, err := keyer.CreateSurfaceWithChromaKey(bitmap, 0, 1.0, gopi.SURFACE_LAYER_DEFAULT+1, gopi.Point{float32(i)*20 + 4, 4}, gopi.ZeroSize, key); err != nil {
+					return err
+				} else if keyer.ChromaKeyForSurface(s) != key {
+					return fmt.Errorf("Unexpected key %v", keyer.ChromaKeyForSurface(s))
+				} else {
+					*surface3 = s
+				}
+			}
+			return nil
+		},
+		func(gfx gopi.SurfaceManager) error {
+			// Remove the key from the last surface, and check invalid keys
+			keyer := gfx.(surface.SurfaceChromaKeyer)
+			if err := keyer.SetChromaKey(*surface3, surface.ChromaKey{surface.CHROMA_KEY_RGB, [3]uint8{1, 0, 0}, [3]uint8{0, 0, 0}}); err != gopi.ErrBadParameter {
+				return fmt.Errorf("Expected ErrBadParameter, got %v", err)
+			} else {
+				return keyer.SetChromaKey(*surface3, surface.ChromaKey{})
+			}
+		},
+	}})
+}
+
+func Test_Blit_000(t *testing.T) {
+	bitmap := new(gopi.Bitmap)
+	run_golden(t, golden{"blit", []gopi.SurfaceManagerCallback{
+		func(gfx gopi.SurfaceManager) error {
+			return create_bitmap_surface(gfx, bitmap, gopi.SURFACE_FLAG_RGB888, gopi.Size{GOLDEN_WIDTH, GOLDEN_HEIGHT}, 0, 1.0, gopi.SURFACE_LAYER_DEFAULT, gopi.ZeroPoint, blue)
+		},
+		func(gfx gopi.SurfaceManager) error {
+			// A translucent source with an opaque red square in the top left
+			blitter := gfx.(surface.BitmapBlitter)
+			if src, err := gfx.CreateBitmap(gopi.SURFACE_FLAG_BITMAP|gopi.SURFACE_FLAG_RGBA32, gopi.Size{16, 16}); err != nil {
+				return err
+			} else if err := src.ClearToColor(gopi.Color{0, 1, 0, 0.5}); err != nil {
+				return err
+			} else if err := src.FillRectToColor(gopi.ZeroPoint, gopi.Size{8, 8}, red); err != nil {
+				return err
+			} else if err := blitter.Blit(*bitmap, src, image.ZR, gopi.Point{-4, -4}, surface.BLIT_MODE_COPY); err != nil {
+				return err
+			} else if err := blitter.Blit(*bitmap, src, image.ZR, gopi.Point{20, 4}, surface.BLIT_MODE_SRC_OVER); err != nil {
+				return err
+			} else if err := blitter.Blit(*bitmap, src, image.ZR, gopi.Point{56, 4}, surface.BlitModeWithOpacity(surface.BLIT_MODE_SRC_OVER, 0.5)); err != nil {
+				return err
+			} else if err := blitter.Blit(*bitmap, src, image.Rect(4, 4, 12, 12), gopi.Point{4, 28}, surface.BLIT_MODE_COPY); err != nil {
+				return err
+			} else if err := blitter.Blit(*bitmap, src, image.ZR, gopi.Point{48, 28}, surface.BlitModeWithOpacity(surface.BLIT_MODE_COPY, 0.5)); err != nil {
+				// A copy with opacity is blended as the destination has no alpha
+				return err
+			} else {
+				// Overlapping copy within the destination
+				return blitter.Blit(*bitmap, *bitmap, image.Rect(20, 4, 36, 20), gopi.Point{28, 24}, surface.BLIT_MODE_COPY)
+			}
+		},
+	}})
+}
+
+func Test_BlitMode_000(t *testing.T) {
+	// Modes are opaque unless an opacity is set
+	if opacity := surface.BLIT_MODE_SRC_OVER.Opacity(); opacity != 1.0 {
+		t.Error("Expected opaque mode, got", opacity)
+	} else if mode := surface.BlitModeWithOpacity(surface.BLIT_MODE_COPY, 1.0); mode != surface.BLIT_MODE_COPY {
+		t.Error("Unexpected mode", mode)
+	} else if opacity := surface.BlitModeWithOpacity(surface.BLIT_MODE_COPY, 0).Opacity(); opacity != 0 {
+		t.Error("Expected transparent mode, got", opacity)
+	} else if mode := surface.BlitModeWithOpacity(surface.BLIT_MODE_SRC_OVER, 0.5); mode&surface.BLIT_MODE_MASK != surface.BLIT_MODE_SRC_OVER || mode.Opacity() < 0.49 || mode.Opacity() > 0.51 {
+		t.Error("Unexpected mode", mode)
+	}
+}
+
+func Test_Do_000(t *testing.T) {
+	// Updates cannot be made outside of Do
+	gfx := open_manager(t)
+	defer gfx.Close()
+	if b, err := gfx.CreateBitmap(gopi.SURFACE_FLAG_BITMAP, gopi.Size{2, 2}); err != nil {
+		t.Fatal(err)
+	} else if _, err := gfx.CreateSurfaceWithBitmap(b, 0, 1.0, gopi.SURFACE_LAYER_DEFAULT, gopi.ZeroPoint, gopi.ZeroSize); err != gopi.ErrOutOfOrder {
+		t.Error("Expected ErrOutOfOrder, got", err)
+	}
+}
+
+func Test_DestroySurface_000(t *testing.T) {
+	// A bitmap shared by two surfaces is in use until both are destroyed
+	gfx := open_manager(t)
+	defer gfx.Close()
+	b, err := gfx.CreateBitmap(gopi.SURFACE_FLAG_BITMAP, gopi.Size{2, 2})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := gfx.Do(func(gfx gopi.SurfaceManager) error {
+		if s1, err := gfx.CreateSurfaceWithBitmap(b, 0, 1.0, gopi.SURFACE_LAYER_DEFAULT, gopi.ZeroPoint, gopi.ZeroSize); err != nil {
+			return err
+		} else if s2, err := gfx.CreateSurfaceWithBitmap(b, 0, 1.0, gopi.SURFACE_LAYER_DEFAULT, gopi.ZeroPoint, gopi.ZeroSize); err != nil {
+			return err
+		} else if s3, err := gfx.CreateSurface(gopi.SURFACE_FLAG_BITMAP, 1.0, gopi.SURFACE_LAYER_DEFAULT, gopi.ZeroPoint, gopi.Size{2, 2}); err != nil {
+			return err
+		} else if err := gfx.DestroySurface(s1); err != nil {
+			return err
+		} else if err := gfx.DestroyBitmap(b); err != surface.ErrBitmapInUse {
+			return fmt.Errorf("Expected ErrBitmapInUse, got %v", err)
+		} else if err := gfx.DestroySurface(s2); err != nil {
+			return err
+		} else {
+			return gfx.DestroySurface(s3)
+		}
+	}); err != nil {
+		t.Fatal(err)
+	} else if err := gfx.DestroyBitmap(b); err != nil {
+		t.Error(err)
+	}
+}
+
+func Test_DestroySurface_001(t *testing.T) {
+	// A bitmap created with a surface and drawn by another surface is
+	// destroyed with the last surface which draws it
+	gfx := open_manager(t)
+	defer gfx.Close()
+	resources := gfx.(surface.Resources)
+	if err := gfx.Do(func(gfx gopi.SurfaceManager) error {
+		if s1, err := gfx.CreateSurface(gopi.SURFACE_FLAG_BITMAP, 1.0, gopi.SURFACE_LAYER_DEFAULT, gopi.ZeroPoint, gopi.Size{2, 2}); err != nil {
+			return err
+		} else if bitmaps := resources.Bitmaps(); len(bitmaps) != 1 || bitmaps[0].Surfaces != 1 {
+			return fmt.Errorf("Unexpected bitmaps %v", bitmaps)
+		} else if s2, err := gfx.CreateSurfaceWithBitmap(bitmaps[0].Bitmap, 0, 1.0, gopi.SURFACE_LAYER_DEFAULT, gopi.ZeroPoint, gopi.ZeroSize); err != nil {
+			return err
+		} else if err := gfx.DestroySurface(s1); err != nil {
+			return err
+		} else if bitmaps := resources.Bitmaps(); len(bitmaps) != 1 || bitmaps[0].Surfaces != 1 {
+			return fmt.Errorf("Unexpected bitmaps %v", bitmaps)
+		} else if err := gfx.DestroySurface(s2); err != nil {
+			return err
+		} else if bitmaps := resources.Bitmaps(); len(bitmaps) != 0 {
+			return fmt.Errorf("Expected bitmap to be destroyed, got %v", bitmaps)
+		} else if surfaces := resources.Surfaces(); len(surfaces) != 0 {
+			return fmt.Errorf("Unexpected surfaces %v", surfaces)
+		} else {
+			return nil
+		}
+	}); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func Test_Fade_000(t *testing.T) {
+	// Fade two surfaces while making other updates, and cancel a fade
+	gfx := open_manager(t)
+	defer gfx.Close()
+	fader := gfx.(surface.SurfaceFader)
+	surfaces := make([]gopi.Surface, 2)
+	if err := gfx.Do(func(gfx gopi.SurfaceManager) error {
+		for i := range surfaces {
+			if s, err := gfx.CreateSurface(gopi.SURFACE_FLAG_BITMAP, 0.0, gopi.SURFACE_LAYER_DEFAULT, gopi.ZeroPoint, gopi.Size{2, 2}); err != nil {
+				return err
+			} else {
+				surfaces[i] = s
+			}
+		}
+		return nil
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	done1, err := fader.Fade(surfaces[0], 0.0, 1.0, 50*time.Millisecond, surface.EaseInOut)
+	if err != nil {
+		t.Fatal(err)
+	}
+	done2, err := fader.Fade(surfaces[1], 0.0, 1.0, time.Hour, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := fader.Fade(surfaces[1], 0.0, 1.5, time.Second, nil); err != gopi.ErrBadParameter {
+		t.Error("Expected ErrBadParameter, got", err)
+	}
+	for i := 0; i < 10; i++ {
+		if err := gfx.Do(func(gfx gopi.SurfaceManager) error {
+			return gfx.MoveOriginBy(surfaces[1], gopi.Point{1, 1})
+		}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	select {
+	case <-done1:
+		if opacity := surfaces[0].Opacity(); opacity != 1.0 {
+			t.Error("Unexpected opacity", opacity)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("Timeout waiting for fade")
+	}
+	if err := fader.CancelFade(surfaces[1]); err != nil {
+		t.Error(err)
+	}
+	select {
+	case <-done2:
+		if opacity := surfaces[1].Opacity(); opacity >= 0.5 {
+			t.Error("Unexpected opacity", opacity)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("Timeout waiting for cancel")
+	}
+}
+
+func Test_DoAsync_000(t *testing.T) {
+	// Submit an update in the background, and check the next update
+	// follows it
+	gfx := open_manager(t)
+	defer gfx.Close()
+	updater := gfx.(surface.AsyncUpdater)
+	var s gopi.Surface
+	if _, err := updater.DoAsync(nil); err != gopi.ErrBadParameter {
+		t.Error("Expected ErrBadParameter, got", err)
+	}
+	done, err := updater.DoAsync(func(gfx gopi.SurfaceManager) error {
+		var err error
+		s, err = gfx.CreateSurface(gopi.SURFACE_FLAG_BITMAP, 1.0, gopi.SURFACE_LAYER_DEFAULT, gopi.ZeroPoint, gopi.Size{2, 2})
+		return err
+	})
+	if err != nil {
+		t.Fatal(err)
+	} else if err := gfx.Do(func(gfx gopi.SurfaceManager) error {
+		return gfx.MoveOriginBy(s, gopi.Point{1, 1})
+	}); err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Error(err)
+		} else if origin := s.Origin(); origin != (gopi.Point{1, 1}) {
+			t.Error("Unexpected origin", origin)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("Timeout waiting for update")
+	}
+}
+
+func Test_FrameCallback_000(t *testing.T) {
+	// Move a surface for each refresh of the simulated display, and
+	// remove the callback after five frames
+	gfx := open_manager(t)
+	defer gfx.Close()
+	scheduler := gfx.(surface.FrameScheduler)
+	s := new(gopi.Surface)
+	if err := gfx.Do(func(gfx gopi.SurfaceManager) error {
+		return create_surface(gfx, s, gopi.SURFACE_FLAG_RGB888, gopi.Size{2, 2}, 0, 1.0, gopi.SURFACE_LAYER_DEFAULT, gopi.ZeroPoint, red)
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	count := 0
+	frames := make(chan time.Time, 5)
+	id, err := scheduler.AddFrameCallback(func(gfx gopi.SurfaceManager, t time.Time) {
+		if err := gfx.MoveOriginBy(*s, gopi.Point{1, 0}); err == nil {
+			count++
+		}
+		select {
+		case frames <- t:
+		default:
+		}
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var last time.Time
+	for i := 0; i < cap(frames); i++ {
+		select {
+		case frame := <-frames:
+			if last.IsZero() == false && frame.Sub(last)%(time.Second/surface.REFRESH_RATE_DEFAULT) != 0 {
+				t.Error("Frame is not aligned to the refresh", frame.Sub(last))
+			}
+			last = frame
+		case <-time.After(5 * time.Second):
+			t.Fatal("Timeout waiting for frame")
+		}
+	}
+	if err := scheduler.RemoveFrameCallback(id); err != nil {
+		t.Fatal(err)
+	} else if err := scheduler.RemoveFrameCallback(id); err != gopi.ErrBadParameter {
+		t.Error("Expected ErrBadParameter, got", err)
+	}
+	if err := gfx.Do(func(gfx gopi.SurfaceManager) error {
+		if count < cap(frames) {
+			return fmt.Errorf("Unexpected number of frames %v", count)
+		} else if origin := (*s).Origin(); origin != (gopi.Point{float32(count), 0}) {
+			return fmt.Errorf("Unexpected origin %v", origin)
+		}
+		return nil
+	}); err != nil {
+		t.Error(err)
+	}
+}
+
+func Test_Resources_000(t *testing.T) {
+	// Destroyed surfaces and bitmaps are removed from the lists
+	gfx := open_manager(t)
+	defer gfx.Close()
+	resources := gfx.(surface.Resources)
+	if err := gfx.Do(func(gfx gopi.SurfaceManager) error {
+		if s1, err := gfx.CreateSurface(gopi.SURFACE_FLAG_BITMAP|gopi.SURFACE_FLAG_RGB565, 1.0, gopi.SURFACE_LAYER_DEFAULT, gopi.ZeroPoint, gopi.Size{10, 10}); err != nil {
+			return err
+		} else if _, err := gfx.CreateSurface(gopi.SURFACE_FLAG_BITMAP|gopi.SURFACE_FLAG_RGBA32, 1.0, gopi.SURFACE_LAYER_DEFAULT, gopi.ZeroPoint, gopi.Size{10, 10}); err != nil {
+			return err
+		} else if surfaces, bitmaps := resources.Surfaces(), resources.Bitmaps(); len(surfaces) != 2 || len(bitmaps) != 2 {
+			return fmt.Errorf("Unexpected surfaces=%v bitmaps=%v", surfaces, bitmaps)
+		} else if bitmaps[0].Bytes != uint(bitmaps[0].Stride)*10 || bitmaps[0].Surfaces != 1 {
+			return fmt.Errorf("Unexpected bitmap %v", bitmaps[0])
+		} else {
+			return gfx.DestroySurface(s1)
+		}
+	}); err != nil {
+		t.Fatal(err)
+	} else if surfaces, bitmaps := resources.Surfaces(), resources.Bitmaps(); len(surfaces) != 1 || len(bitmaps) != 1 {
+		t.Errorf("Unexpected surfaces=%v bitmaps=%v", surfaces, bitmaps)
+	} else if bitmaps[0].Type != gopi.SURFACE_FLAG_RGBA32 || bitmaps[0].Bytes != uint(bitmaps[0].Stride)*10 {
+		t.Errorf("Unexpected bitmap %v", bitmaps[0])
+	}
+}
+
+////////////////////////////////////////////////////////////////////////////////
+// HARNESS
+
+func open_manager(t *testing.T) gopi.SurfaceManager {
+	t.Helper()
+	return open_manager_with_transform(t, surface.SURFACE_TRANSFORM_NONE)
+}
+
+func open_manager_with_transform(t *testing.T, transform surface.Transform) gopi.SurfaceManager {
+	t.Helper()
+	if d, err := gopi.Open(display.VirtualDisplay{Width: GOLDEN_WIDTH, Height: GOLDEN_HEIGHT}, logger.New(t)); err != nil {
+		t.Fatal(err)
+	} else if gfx, err := gopi.Open(surface.SurfaceManager{Display: d.(gopi.Display), Transform: transform}, logger.New(t)); err != nil {
+		t.Fatal(err)
+	} else {
+		return gfx.(gopi.SurfaceManager)
+	}
+	return nil
+}
+
+func run_golden(t *testing.T, test golden) {
+	t.Helper()
+	run_golden_with_transform(t, test, surface.SURFACE_TRANSFORM_NONE)
+}
+
+// run_golden_with_transform runs a golden test where the display
+// transform is applied to all surfaces and the snapshot
+func run_golden_with_transform(t *testing.T, test golden, transform surface.Transform) {
+	t.Helper()
+
+	// Run each step in an update
+	gfx := open_manager_with_transform(t, transform)
+	defer gfx.Close()
+	for i, step := range test.steps {
+		if err := gfx.Do(step); err != nil {
+			t.Fatalf("%v: step %v: %v", test.name, i, err)
+		}
+	}
+
+	// Snapshot the display
+	actual := snapshot(t, gfx)
+	path := filepath.Join("testdata", test.name+".png")
+	if *flagUpdate {
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		} else if err := write_png(path, actual); err != nil {
+			t.Fatal(err)
+		}
+		t.Logf("%v: updated %v", test.name, path)
+		return
+	}
+
+	// Compare with the golden image and write the actual and difference
+	// images when they differ
+	expected, err := read_png(path)
+	if err != nil {
+		t.Fatalf("%v: %v (run with -golden.update to create)", test.name, err)
+	}
+	if diff, count := compare(expected, actual, uint8(*flagTolerance)); count > 0 {
+		t.Errorf("%v: %v pixels differ from %v", test.name, count, path)
+		if err := os.MkdirAll(*flagDiff, 0755); err != nil {
+			t.Fatal(err)
+		}
+		for suffix, img := range map[string]image.Image{"actual": actual, "diff": diff} {
+			path := filepath.Join(*flagDiff, fmt.Sprintf("%v.%v.png", test.name, suffix))
+			if err := write_png(path, img); err != nil {
+				t.Fatal(err)
+			}
+			t.Logf("%v: wrote %v", test.name, path)
+		}
+	}
+}
+
+func create_bitmap_surface(gfx gopi.SurfaceManager, b *gopi.Bitmap, config gopi.SurfaceFlags, size gopi.Size, flags gopi.SurfaceFlags, opacity float32, layer uint16, origin gopi.Point, c gopi.Color) error {
+	if bitmap, err := gfx.CreateBitmap(gopi.SURFACE_FLAG_BITMAP|config, size); err != nil {
+		return err
+	} else if err := bitmap.ClearToColor(c); err != nil {
+		return err
+	} else if _, err := gfx.CreateSurfaceWithBitmap(bitmap, flags, opacity, layer, origin, size); err != nil {
+		return err
+	} else {
+		*b = bitmap
+		return nil
+	}
+}
+
+// create_transform_surfaces draws a 12x6 bitmap with a marker in the top
+// left corner, rotated and flipped in each row and column
+func create_transform_surfaces(gfx gopi.SurfaceManager) error {
+	transformer := gfx.(surface.SurfaceTransformer)
+	bitmap, err := gfx.CreateBitmap(gopi.SURFACE_FLAG_BITMAP|gopi.SURFACE_FLAG_RGB888, gopi.Size{12, 6})
+	if err != nil {
+		return err
+	} else if err := bitmap.ClearToColor(red); err != nil {
+		return err
+	} else if err := bitmap.FillRectToColor(gopi.ZeroPoint, gopi.Size{3, 3}, green); err != nil {
+		return err
+	}
+	for i, transform := range []surface.Transform{
+		surface.SURFACE_TRANSFORM_NONE,
+		surface.SURFACE_TRANSFORM_ROTATE_90,
+		surface.SURFACE_TRANSFORM_ROTATE_180,
+		surface.SURFACE_TRANSFORM_ROTATE_270,
+		surface.SURFACE_TRANSFORM_FLIP_H,
+		surface.SURFACE_TRANSFORM_FLIP_V,
+	} {
+		origin := gopi.Point{float32(i%3) * 16, float32(i/3) * 16}
+		if s, err := transformer.CreateSurfaceWithTransform(bitmap, 0, 1.0, gopi.SURFACE_LAYER_DEFAULT, origin, gopi.ZeroSize, transform); err != nil {
+			return err
+		} else if transformer.TransformForSurface(s) != transform {
+			return fmt.Errorf("Unexpected transform %v", transformer.TransformForSurface(s))
+		}
+	}
+	return nil
+}
+
+func create_surface(gfx gopi.SurfaceManager, s *gopi.Surface, config gopi.SurfaceFlags, size gopi.Size, flags gopi.SurfaceFlags, opacity float32, layer uint16, origin gopi.Point, c gopi.Color) error {
+	if bitmap, err := gfx.CreateBitmap(gopi.SURFACE_FLAG_BITMAP|config, size); err != nil {
+		return err
+	} else if err := bitmap.ClearToColor(c); err != nil {
+		return err
+	} else if surface, err := gfx.CreateSurfaceWithBitmap(bitmap, flags, opacity, layer, origin, size); err != nil {
+		return err
+	} else {
+		*s = surface
+		return nil
+	}
+}
+
+func snapshot(t *testing.T, gfx gopi.SurfaceManager) *image.NRGBA {
+	t.Helper()
+	if bitmap, err := gfx.CreateSnapshot(gopi.SURFACE_FLAG_RGB888); err != nil {
+		t.Fatal(err)
+	} else if img, err := bitmap.(surface.PixelBitmap).ReadPixels(gopi.ZeroPoint, bitmap.Size()); err != nil {
+		t.Fatal(err)
+	} else {
+		return img
+	}
+	return nil
+}
+
+// compare returns an image where pixels which differ by more than the
+// tolerance are red on a faded copy of the expected image, and the
+// number of pixels which differ
+func compare(expected image.Image, actual *image.NRGBA, tolerance uint8) (*image.NRGBA, uint) {
+	bounds := actual.Bounds()
+	diff := image.NewNRGBA(bounds)
+	count := uint(0)
+	if expected.Bounds() != bounds {
+		return diff, uint(bounds.Dx() * bounds.Dy())
+	}
+	for y := bounds.Min.Y; y < bounds.Max.Y; y++ {
+		for x := bounds.Min.X; x < bounds.Max.X; x++ {
+			a := color.NRGBAModel.Convert(expected.At(x, y)).(color.NRGBA)
+			b := actual.NRGBAAt(x, y)
+			if component_differs(a.R, b.R, tolerance) || component_differs(a.G, b.G, tolerance) || component_differs(a.B, b.B, tolerance) || component_differs(a.A, b.A, tolerance) {
+				diff.SetNRGBA(x, y, color.NRGBA{0xFF, 0x00, 0x00, 0xFF})
+				count++
+			} else {
+				diff.SetNRGBA(x, y, color.NRGBA{a.R, a.G, a.B, 0x40})
+			}
+		}
+	}
+	return diff, count
+}
+
+func component_differs(a, b, tolerance uint8) bool {
+	if a > b {
+		return a-b > tolerance
+	} else {
+		return b-a > tolerance
+	}
+}
+
+func read_png(path string) (image.Image, error) {
+	if fh, err := os.Open(path); err != nil {
+		return nil, err
+	} else {
+		defer fh.Close()
+		return png.Decode(fh)
+	}
+}
+
+func write_png(path string, img image.Image) error {
+	if fh, err := os.Create(path); err != nil {
+		return err
+	} else if err := png.Encode(fh, img); err != nil {
+		fh.Close()
+		return err
+	} else {
+		return fh.Close()
+	}
+}
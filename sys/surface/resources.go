@@ -0,0 +1,127 @@
+/*
+  Go Language Raspberry Pi Interface
+  (c) Copyright David Thorpe 2016-2019
+  All Rights Reserved
+
+  Documentation http://djthorpe.github.io/gopi/
+  For Licensing and Usage information, please see LICENSE.md
+*/
+
+package surface
+
+import (
+	"fmt"
+
+	// Frameworks
+	gopi "github.com/djthorpe/gopi"
+)
+
+////////////////////////////////////////////////////////////////////////////////
+// TYPES
+
+// Resources is implemented by the surface manager in addition to
+// gopi.SurfaceManager, in order to list the surfaces and bitmaps which
+// have been created and not yet destroyed
+type Resources interface {
+	// Return the surfaces which have not been destroyed
+	Surfaces() []gopi.Surface
+
+	// Return the bitmaps which have not been destroyed
+	Bitmaps() []BitmapInfo
+}
+
+// BitmapInfo describes a bitmap, where the number of bytes is the
+// memory used by the pixel data, and surfaces is the number of surfaces
+// which draw the bitmap
+type BitmapInfo struct {
+	Bitmap   gopi.Bitmap
+	Type     gopi.SurfaceFlags
+	Size     gopi.Size
+	Stride   uint32
+	Bytes    uint
+	Surfaces uint
+}
+
+////////////////////////////////////////////////////////////////////////////////
+// RESOURCES
+
+func (this *manager) Surfaces() []gopi.Surface {
+	this.Lock()
+	defer this.Unlock()
+	surfaces := make([]gopi.Surface, len(this.surfaces))
+	for i, surface := range this.surfaces {
+		surfaces[i] = surface
+	}
+	return surfaces
+}
+
+func (this *manager) Bitmaps() []BitmapInfo {
+	this.Lock()
+	defer this.Unlock()
+	bitmaps := make([]BitmapInfo, len(this.bitmaps))
+	for i, bitmap := range this.bitmaps {
+		bitmaps[i] = bitmap.info()
+	}
+	return bitmaps
+}
+
+////////////////////////////////////////////////////////////////////////////////
+// STRINGIFY
+
+func (this BitmapInfo) String() string {
+	return fmt.Sprintf("<graphics.surfacemanager.BitmapInfo>{ type=%v size=%v stride=%v bytes=%v surfaces=%v }", this.Type, this.Size, this.Stride, this.Bytes, this.Surfaces)
+}
+
+////////////////////////////////////////////////////////////////////////////////
+// PRIVATE METHODS
+
+func (this *bitmap) info() BitmapInfo {
+	this.Lock()
+	defer this.Unlock()
+	return BitmapInfo{
+		Bitmap:   this,
+		Type:     this.flags.Config(),
+		Size:     gopi.Size{float32(this.size.W), float32(this.size.H)},
+		Stride:   this.stride,
+		Bytes:    uint(this.stride) * uint(this.size.H),
+		Surfaces: this.ref,
+	}
+}
+
+// add_surface adds a created surface to the list of surfaces
+func (this *manager) add_surface(s *surface) {
+	this.Lock()
+	defer this.Unlock()
+	this.surfaces = append(this.surfaces, s)
+}
+
+// add_bitmap adds a created bitmap to the list of bitmaps
+func (this *manager) add_bitmap(b *bitmap) {
+	this.Lock()
+	defer this.Unlock()
+	this.bitmaps = append(this.bitmaps, b)
+}
+
+// remove_surface removes a destroyed surface from the list of surfaces
+func (this *manager) remove_surface(s *surface) {
+	this.Lock()
+	defer this.Unlock()
+	for i := range this.surfaces {
+		if this.surfaces[i] == s {
+			this.surfaces = append(this.surfaces[:i], this.surfaces[i+1:]...)
+			return
+		}
+	}
+}
+
+// remove_bitmap removes a destroyed bitmap from the list of bitmaps
+func (this *manager) remove_bitmap(b *bitmap) {
+	this.Lock()
+	defer this.Unlock()
+	for i := range this.bitmaps {
+		if this.bitmaps[i] == b {
+			this.bitmaps = append(this.bitmaps[:i], this.bitmaps[i+1:]...)
+			return
+		}
+	}
+}
@@ -0,0 +1,127 @@
+/*
+  Go Language Raspberry Pi Interface
+  (c) Copyright David Thorpe 2016-2019
+  All Rights Reserved
+
+  Documentation http://djthorpe.github.io/gopi/
+  For Licensing and Usage information, please see LICENSE.md
+*/
+
+package surface
+
+import (
+	// Frameworks
+	gopi "github.com/djthorpe/gopi"
+)
+
+////////////////////////////////////////////////////////////////////////////////
+// TYPES
+
+// SurfaceScaler is implemented by the surface manager in addition to
+// gopi.SurfaceManager, in order to resize surfaces within an update
+type SurfaceScaler interface {
+	// Set the size of a surface, where the mode determines whether the
+	// bitmap is scaled to the new size or clipped to it. SetSize scales
+	// the bitmap
+	SetSizeWithMode(s gopi.Surface, size gopi.Size, mode SizeMode) error
+}
+
+// SurfaceBitmapSetter is implemented by the surface manager in addition
+// to gopi.SurfaceManager, in order to change the bitmap drawn by a surface
+// within an update, for double-buffering and animation
+type SurfaceBitmapSetter interface {
+	// Set the bitmap drawn by a surface, which must have the same pixel
+	// format as the current bitmap and contain the part of the bitmap which
+	// is drawn. The current bitmap can be destroyed once it is no longer
+	// drawn by any surface
+	SetSurfaceBitmap(s gopi.Surface, b gopi.Bitmap) error
+}
+
+// SizeMode determines how the bitmap is drawn when a surface is resized
+type SizeMode uint
+
+////////////////////////////////////////////////////////////////////////////////
+// CONSTANTS
+
+const (
+	// Scale the part of the bitmap which is drawn to the new size
+	SURFACE_SIZE_SCALE SizeMode = iota
+
+	// Draw the bitmap without scaling from the top left of the part of
+	// the bitmap which is drawn, which must fit within the bitmap
+	SURFACE_SIZE_CLIP
+)
+
+////////////////////////////////////////////////////////////////////////////////
+// STRINGIFY
+
+func (m SizeMode) String() string {
+	switch m {
+	case SURFACE_SIZE_SCALE:
+		return "SURFACE_SIZE_SCALE"
+	case SURFACE_SIZE_CLIP:
+		return "SURFACE_SIZE_CLIP"
+	default:
+		return "[?? Invalid SizeMode value]"
+	}
+}
+
+////////////////////////////////////////////////////////////////////////////////
+// PRIVATE METHODS
+
+// set_bitmap sets the bitmap drawn by a surface, retaining the new
+// bitmap and releasing the current bitmap
+func (this *surface) set_bitmap(b gopi.Bitmap) {
+	if bitmap_, ok := b.(*bitmap); ok {
+		bitmap_.retain()
+	}
+	if bitmap_, ok := this.bitmap.(*bitmap); ok {
+		bitmap_.release()
+	}
+	this.bitmap = b
+}
+
+// set_owned sets the bitmap which was created with a surface, so that
+// it is destroyed with the surface
+func (this *manager) set_owned(s gopi.Surface, b gopi.Bitmap) {
+	if surface_, ok := s.(*surface); ok {
+		surface_.owned = b
+	}
+}
+
+// destroy_owned destroys the bitmap which was created with a surface.
+// When the bitmap is still drawn by another surface, that surface takes
+// ownership so that the bitmap is destroyed with the last surface, or
+// when the manager is closed if no surface can take ownership
+func (this *manager) destroy_owned(s *surface) error {
+	if s.owned == nil {
+		return nil
+	} else if bitmap_, ok := s.owned.(*bitmap); ok && bitmap_.in_use() {
+		if other := this.surface_to_own(bitmap_); other != nil {
+			this.log.Debug("<graphics.surfacemanager>DestroySurface: bitmap=%v is owned by surface=%v", bitmap_, other)
+			other.owned = s.owned
+		} else {
+			this.log.Debug("<graphics.surfacemanager>DestroySurface: bitmap=%v is in use until Close", bitmap_)
+		}
+		s.owned = nil
+		return nil
+	} else if err := this.DestroyBitmap(s.owned); err != nil {
+		return err
+	} else {
+		s.owned = nil
+		return nil
+	}
+}
+
+// surface_to_own returns a surface which draws a bitmap and does not own
+// a bitmap, or nil
+func (this *manager) surface_to_own(b *bitmap) *surface {
+	this.Lock()
+	defer this.Unlock()
+	for _, surface := range this.surfaces {
+		if surface.bitmap == gopi.Bitmap(b) && surface.owned == nil {
+			return surface
+		}
+	}
+	return nil
+}
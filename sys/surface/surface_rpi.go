@@ -45,5 +45,5 @@ func (this *surface) Layer() uint16 {
 // STRINGIFY
 
 func (this *surface) String() string {
-	return fmt.Sprintf("<graphics.surface>{ id=0x%08X flags=%v size=%v origin=%v opacity=%v layer=%v }", this.native.handle, this.flags, this.native.size, this.native.origin, this.opacity, this.layer)
+	return fmt.Sprintf("<graphics.surface>{ id=0x%08X flags=%v size=%v origin=%v opacity=%v layer=%v transform=%v }", this.native.handle, this.flags, this.native.size, this.native.origin, this.opacity, this.layer, this.transform)
 }
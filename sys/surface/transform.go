@@ -0,0 +1,219 @@
+/*
+  Go Language Raspberry Pi Interface
+  (c) Copyright David Thorpe 2016-2019
+  All Rights Reserved
+
+  Documentation http://djthorpe.github.io/gopi/
+  For Licensing and Usage information, please see LICENSE.md
+*/
+
+package surface
+
+import (
+	"image"
+	"strings"
+
+	// Frameworks
+	gopi "github.com/djthorpe/gopi"
+)
+
+////////////////////////////////////////////////////////////////////////////////
+// TYPES
+
+// SurfaceTransformer is implemented by the surface manager in addition to
+// gopi.SurfaceManager, in order to rotate and flip surfaces
+type SurfaceTransformer interface {
+	// Create a surface with a bitmap and a transform within an update
+	CreateSurfaceWithTransform(bitmap gopi.Bitmap, flags gopi.SurfaceFlags, opacity float32, layer uint16, origin gopi.Point, size gopi.Size, transform Transform) (gopi.Surface, error)
+
+	// Set the transform for a surface within an update
+	SetTransform(s gopi.Surface, transform Transform) error
+
+	// Return the transform for a surface
+	TransformForSurface(s gopi.Surface) Transform
+
+	// Return the transform for the display, which is applied to
+	// every surface and to snapshots
+	DisplayTransform() Transform
+}
+
+// Transform rotates and flips a surface, where the bitmap is flipped
+// and then rotated clockwise. The size of a surface is the size after
+// the transform is applied
+type Transform uint
+
+////////////////////////////////////////////////////////////////////////////////
+// CONSTANTS
+
+const (
+	SURFACE_TRANSFORM_NONE       Transform = 0x00
+	SURFACE_TRANSFORM_ROTATE_90  Transform = 0x01
+	SURFACE_TRANSFORM_ROTATE_180 Transform = 0x02
+	SURFACE_TRANSFORM_ROTATE_270 Transform = 0x03
+	SURFACE_TRANSFORM_ROTATE     Transform = 0x03 // Mask for rotation
+	SURFACE_TRANSFORM_FLIP_H     Transform = 0x10
+	SURFACE_TRANSFORM_FLIP_V     Transform = 0x20
+	SURFACE_TRANSFORM_FLIP       Transform = 0x30 // Mask for flipping
+	SURFACE_TRANSFORM_MAX        Transform = SURFACE_TRANSFORM_ROTATE | SURFACE_TRANSFORM_FLIP
+)
+
+////////////////////////////////////////////////////////////////////////////////
+// PUBLIC METHODS
+
+// TransformForRotation returns the transform for a clockwise rotation in
+// degrees, which is 0, 90, 180 or 270
+func TransformForRotation(degrees uint) (Transform, error) {
+	switch degrees % 360 {
+	case 0:
+		return SURFACE_TRANSFORM_NONE, nil
+	case 90:
+		return SURFACE_TRANSFORM_ROTATE_90, nil
+	case 180:
+		return SURFACE_TRANSFORM_ROTATE_180, nil
+	case 270:
+		return SURFACE_TRANSFORM_ROTATE_270, nil
+	default:
+		return SURFACE_TRANSFORM_NONE, gopi.ErrBadParameter
+	}
+}
+
+// Then returns the transform which applies this transform followed by
+// another transform
+func (t Transform) Then(other Transform) Transform {
+	return transform_from_matrix(other.matrix().multiply(t.matrix()))
+}
+
+// Inverse returns the transform which reverses this transform
+func (t Transform) Inverse() Transform {
+	return transform_from_matrix(t.matrix().transpose())
+}
+
+// Swaps returns true if the transform swaps width and height
+func (t Transform) Swaps() bool {
+	return t.matrix()[0] == 0
+}
+
+////////////////////////////////////////////////////////////////////////////////
+// TRANSFORMS
+
+func (this *manager) TransformForSurface(s gopi.Surface) Transform {
+	if surface_, ok := s.(*surface); ok == false {
+		return SURFACE_TRANSFORM_NONE
+	} else {
+		return surface_.transform
+	}
+}
+
+func (this *manager) DisplayTransform() Transform {
+	return this.transform
+}
+
+////////////////////////////////////////////////////////////////////////////////
+// STRINGIFY
+
+func (t Transform) String() string {
+	if t == SURFACE_TRANSFORM_NONE {
+		return "SURFACE_TRANSFORM_NONE"
+	} else if t > SURFACE_TRANSFORM_MAX {
+		return "[?? Invalid Transform value]"
+	}
+	parts := make([]string, 0, 3)
+	switch t & SURFACE_TRANSFORM_ROTATE {
+	case SURFACE_TRANSFORM_ROTATE_90:
+		parts = append(parts, "SURFACE_TRANSFORM_ROTATE_90")
+	case SURFACE_TRANSFORM_ROTATE_180:
+		parts = append(parts, "SURFACE_TRANSFORM_ROTATE_180")
+	case SURFACE_TRANSFORM_ROTATE_270:
+		parts = append(parts, "SURFACE_TRANSFORM_ROTATE_270")
+	}
+	if t&SURFACE_TRANSFORM_FLIP_H != 0 {
+		parts = append(parts, "SURFACE_TRANSFORM_FLIP_H")
+	}
+	if t&SURFACE_TRANSFORM_FLIP_V != 0 {
+		parts = append(parts, "SURFACE_TRANSFORM_FLIP_V")
+	}
+	return strings.Join(parts, "|")
+}
+
+////////////////////////////////////////////////////////////////////////////////
+// PRIVATE METHODS
+
+// display_size returns the size of the display after the display
+// transform is applied, which is the size surfaces are positioned within
+func (this *manager) display_size() image.Point {
+	w, h := this.display.Size()
+	return transform_size(this.transform, image.Pt(int(w), int(h)))
+}
+
+// transform_matrix maps a vector (x,y) to (m[0]x+m[1]y,m[2]x+m[3]y)
+// where y is downwards
+type transform_matrix [4]int
+
+func (t Transform) matrix() transform_matrix {
+	m := transform_matrix{1, 0, 0, 1}
+	if t&SURFACE_TRANSFORM_FLIP_H != 0 {
+		m = transform_matrix{-1, 0, 0, 1}.multiply(m)
+	}
+	if t&SURFACE_TRANSFORM_FLIP_V != 0 {
+		m = transform_matrix{1, 0, 0, -1}.multiply(m)
+	}
+	for i := Transform(0); i < t&SURFACE_TRANSFORM_ROTATE; i++ {
+		// Rotate clockwise by 90 degrees
+		m = transform_matrix{0, -1, 1, 0}.multiply(m)
+	}
+	return m
+}
+
+func (m transform_matrix) multiply(other transform_matrix) transform_matrix {
+	return transform_matrix{
+		m[0]*other[0] + m[1]*other[2], m[0]*other[1] + m[1]*other[3],
+		m[2]*other[0] + m[3]*other[2], m[2]*other[1] + m[3]*other[3],
+	}
+}
+
+func (m transform_matrix) transpose() transform_matrix {
+	return transform_matrix{m[0], m[2], m[1], m[3]}
+}
+
+// transform_from_matrix returns the simplest transform for a matrix
+func transform_from_matrix(m transform_matrix) Transform {
+	for _, flip := range []Transform{SURFACE_TRANSFORM_NONE, SURFACE_TRANSFORM_FLIP_H} {
+		for rotate := SURFACE_TRANSFORM_NONE; rotate <= SURFACE_TRANSFORM_ROTATE_270; rotate++ {
+			if t := flip | rotate; t.matrix() == m {
+				return t
+			}
+		}
+	}
+	return SURFACE_TRANSFORM_NONE
+}
+
+// transform_size returns a size after the transform is applied
+func transform_size(t Transform, size image.Point) image.Point {
+	if t.Swaps() {
+		return image.Pt(size.Y, size.X)
+	} else {
+		return size
+	}
+}
+
+// transform_rect returns a rectangle within a frame of a size after
+// the transform is applied to the frame
+func transform_rect(t Transform, size image.Point, r image.Rectangle) image.Rectangle {
+	// Corners are mapped using doubled coordinates around the center
+	// of the frame, so that the result is always an integer
+	m, out := t.matrix(), transform_size(t, size)
+	corner := func(p image.Point) image.Point {
+		x, y := 2*p.X-size.X, 2*p.Y-size.Y
+		return image.Pt((m[0]*x+m[1]*y+out.X)/2, (m[2]*x+m[3]*y+out.Y)/2)
+	}
+	return image.Rectangle{corner(r.Min), corner(r.Max)}.Canon()
+}
+
+// transform_pixel returns the pixel within a frame of a size after the
+// transform is applied to the frame
+func transform_pixel(t Transform, size image.Point, p image.Point) image.Point {
+	// Pixel centers are mapped using doubled coordinates
+	m, out := t.matrix(), transform_size(t, size)
+	x, y := 2*p.X+1-size.X, 2*p.Y+1-size.Y
+	return image.Pt((m[0]*x+m[1]*y+out.X-1)/2, (m[2]*x+m[3]*y+out.Y-1)/2)
+}
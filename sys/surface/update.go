@@ -0,0 +1,246 @@
+/*
+  Go Language Raspberry Pi Interface
+  (c) Copyright David Thorpe 2016-2019
+  All Rights Reserved
+
+  Documentation http://djthorpe.github.io/gopi/
+  For Licensing and Usage information, please see LICENSE.md
+*/
+
+package surface
+
+import (
+	"sync"
+	"time"
+
+	// Frameworks
+	gopi "github.com/djthorpe/gopi"
+)
+
+////////////////////////////////////////////////////////////////////////////////
+// TYPES
+
+// AsyncUpdater is implemented by the surface manager in addition to
+// gopi.SurfaceManager, in order to submit updates without waiting for
+// the display to take them
+type AsyncUpdater interface {
+	// Make changes within an update like Do, and submit the update in the
+	// background. The channel receives the result of submitting the update
+	// and is then closed. Updates are submitted in order, so the next
+	// update waits until this update has been submitted
+	DoAsync(callback gopi.SurfaceManagerCallback) (<-chan error, error)
+}
+
+// FrameScheduler is implemented by the surface manager in addition to
+// gopi.SurfaceManager, in order to make changes once for each refresh
+// of the display
+type FrameScheduler interface {
+	// Add a callback which is called once for each refresh of the display,
+	// where the changes made by all callbacks for a frame are made within
+	// a single update. Callbacks must not call Do
+	AddFrameCallback(callback FrameCallback) (FrameCallbackId, error)
+
+	// Remove a callback, which can be called from within a callback
+	RemoveFrameCallback(id FrameCallbackId) error
+}
+
+// FrameCallback is called within an update with the time of the refresh
+type FrameCallback func(gfx gopi.SurfaceManager, t time.Time)
+
+// FrameCallbackId identifies a frame callback
+type FrameCallbackId uint
+
+// frames are the frame callbacks, and the channels to stop the goroutine
+// which calls them
+type frames struct {
+	callbacks []frame_callback
+	next      FrameCallbackId
+	stop      chan struct{}
+	stopped   chan struct{}
+	sync.Mutex
+}
+
+type frame_callback struct {
+	id       FrameCallbackId
+	callback FrameCallback
+}
+
+////////////////////////////////////////////////////////////////////////////////
+// CONSTANTS
+
+const (
+	// The refresh rate in Hz used to pace frames when the refresh
+	// rate of the display is not known
+	REFRESH_RATE_DEFAULT = 60
+)
+
+////////////////////////////////////////////////////////////////////////////////
+// FRAMES
+
+func (this *manager) AddFrameCallback(callback FrameCallback) (FrameCallbackId, error) {
+	this.log.Debug2("<graphics.surfacemanager>AddFrameCallback{ }")
+
+	if callback == nil {
+		return 0, gopi.ErrBadParameter
+	}
+
+	this.frames.Lock()
+	this.frames.next++
+	id := this.frames.next
+	this.frames.callbacks = append(this.frames.callbacks, frame_callback{id, callback})
+	this.frames.Unlock()
+
+	// Start calling frame callbacks
+	this.start_frames()
+
+	// Return success
+	return id, nil
+}
+
+func (this *manager) RemoveFrameCallback(id FrameCallbackId) error {
+	this.log.Debug2("<graphics.surfacemanager>RemoveFrameCallback{ id=%v }", id)
+
+	this.frames.Lock()
+	defer this.frames.Unlock()
+	for i := range this.frames.callbacks {
+		if this.frames.callbacks[i].id == id {
+			this.frames.callbacks = append(this.frames.callbacks[:i], this.frames.callbacks[i+1:]...)
+			return nil
+		}
+	}
+	return gopi.ErrBadParameter
+}
+
+////////////////////////////////////////////////////////////////////////////////
+// PRIVATE METHODS
+
+// do makes changes within an update, and then ends the update. When async
+// is true the update is ended in the background, and the result is sent
+// on the returned channel
+func (this *manager) do(callback gopi.SurfaceManagerCallback, async bool) (<-chan error, error) {
+	if callback == nil {
+		return nil, gopi.ErrBadParameter
+	}
+
+	// Start the update, waiting for any frame or update which is being
+	// submitted to complete
+	this.updating.Lock()
+	if this.in_update() {
+		this.updating.Unlock()
+		return nil, gopi.ErrOutOfOrder
+	} else if err := this.begin_update(); err != nil {
+		this.updating.Unlock()
+		return nil, err
+	}
+	this.updating.Unlock()
+
+	// End the update when the callback returns
+	if async == false {
+		defer this.end_update_sync()
+		return nil, callback(this)
+	} else if err := callback(this); err != nil {
+		this.end_update_sync()
+		return nil, err
+	}
+
+	// End the update in the background, where the next update waits
+	// until this update has ended
+	done := make(chan error, 1)
+	this.updating.Lock()
+	go func() {
+		defer this.updating.Unlock()
+		done <- this.end_update()
+		close(done)
+	}()
+
+	// Return success
+	return done, nil
+}
+
+func (this *manager) end_update_sync() {
+	this.updating.Lock()
+	defer this.updating.Unlock()
+	if err := this.end_update(); err != nil {
+		this.log.Warn("<graphics.surfacemanager>Do: %v", err)
+	}
+}
+
+// start_frames starts the goroutine which calls frame callbacks and
+// applies fades, if it is not already running
+func (this *manager) start_frames() {
+	this.frames.Lock()
+	defer this.frames.Unlock()
+	if this.frames.stop == nil {
+		this.frames.stop, this.frames.stopped = make(chan struct{}), make(chan struct{})
+		go this.run_frames(this.frames.stop, this.frames.stopped)
+	}
+}
+
+// stop_frames removes all frame callbacks and waits for the goroutine
+// which calls them to end
+func (this *manager) stop_frames() {
+	this.frames.Lock()
+	this.frames.callbacks = nil
+	stop, stopped := this.frames.stop, this.frames.stopped
+	this.frames.stop = nil
+	this.frames.Unlock()
+
+	if stop != nil {
+		close(stop)
+		<-stopped
+	}
+}
+
+// run_frames makes an update for each refresh of the display until
+// there are no frame callbacks or fades, or the goroutine is stopped
+func (this *manager) run_frames(stop, stopped chan struct{}) {
+	defer close(stopped)
+	for {
+		if t, ok := this.wait_vsync(stop); ok == false {
+			return
+		} else if this.frame(t) == false {
+			return
+		}
+	}
+}
+
+// frame calls the frame callbacks and applies fades within a single
+// update, unless another update is in progress in which case the frame
+// is skipped. Returns false if there are no frame callbacks or fades
+func (this *manager) frame(t time.Time) bool {
+	this.updating.Lock()
+	defer this.updating.Unlock()
+
+	// End when there are no callbacks or fades
+	this.frames.Lock()
+	callbacks := append([]frame_callback{}, this.frames.callbacks...)
+	if len(callbacks) == 0 && this.fading() == false {
+		this.frames.stop = nil
+		this.frames.Unlock()
+		return false
+	}
+	this.frames.Unlock()
+
+	// Skip the frame when another update is in progress
+	if this.in_update() {
+		return true
+	} else if err := this.begin_update(); err != nil {
+		this.log.Warn("<graphics.surfacemanager>Frame: %v", err)
+		return true
+	}
+
+	// Make the changes for the frame and then signal fades which
+	// are complete once the update has ended
+	for _, callback := range callbacks {
+		callback.callback(this, t)
+	}
+	complete := this.fade_frame(t)
+	if err := this.end_update(); err != nil {
+		this.log.Warn("<graphics.surfacemanager>Frame: %v", err)
+	}
+	for _, f := range complete {
+		close(f.done)
+	}
+
+	return true
+}
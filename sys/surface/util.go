@@ -0,0 +1,102 @@
+/*
+  Go Language Raspberry Pi Interface
+  (c) Copyright David Thorpe 2016-2019
+  All Rights Reserved
+
+  Documentation http://djthorpe.github.io/gopi/
+  For Licensing and Usage information, please see LICENSE.md
+*/
+
+package surface
+
+import (
+	"image"
+	"image/color"
+
+	// Frameworks
+	gopi "github.com/djthorpe/gopi"
+)
+
+////////////////////////////////////////////////////////////////////////////////
+// PRIVATE METHODS
+
+// layer_is_valid returns true if a surface can be created on a layer, which
+// includes the background and cursor layers
+func layer_is_valid(layer uint16) bool {
+	switch layer {
+	case gopi.SURFACE_LAYER_BACKGROUND, gopi.SURFACE_LAYER_CURSOR:
+		return true
+	default:
+		return layer >= gopi.SURFACE_LAYER_DEFAULT && layer <= gopi.SURFACE_LAYER_MAX
+	}
+}
+
+// mask_bounds returns the rectangle of a bitmap covered by a mask
+// drawn at an origin, which is empty if there is no intersection
+func mask_bounds(origin gopi.Point, mask *image.Alpha, size gopi.Size) image.Rectangle {
+	frame := image.Rect(0, 0, int(size.W), int(size.H))
+	return mask.Bounds().Add(image.Pt(int(origin.X), int(origin.Y))).Intersect(frame)
+}
+
+// rect_bounds returns the intersection of a rectangle with a bitmap
+func rect_bounds(origin gopi.Point, size gopi.Size, bitmap gopi.Size) image.Rectangle {
+	frame := image.Rect(0, 0, int(bitmap.W), int(bitmap.H))
+	return image.Rect(int(origin.X), int(origin.Y), int(origin.X)+int(size.W), int(origin.Y)+int(size.H)).Intersect(frame)
+}
+
+// image_bounds returns the rectangle of a bitmap covered by an image
+// drawn at an origin, and the offset from the bitmap to the image
+func image_bounds(origin gopi.Point, img image.Image, bitmap gopi.Size) (image.Rectangle, image.Point) {
+	frame := image.Rect(0, 0, int(bitmap.W), int(bitmap.H))
+	bounds := img.Bounds()
+	delta := bounds.Min.Sub(image.Pt(int(origin.X), int(origin.Y)))
+	return bounds.Sub(delta).Intersect(frame), delta
+}
+
+// blend_over returns a color with coverage between 0 and 255 composited
+// over a destination pixel which may be transparent
+func blend_over(dst color.NRGBA, src color.NRGBA, coverage uint8) color.NRGBA {
+	alpha := uint32(src.A) * uint32(coverage) / 0xFF
+	if alpha == 0 {
+		return dst
+	}
+	dst_alpha := uint32(dst.A) * (0xFF - alpha) / 0xFF
+	out_alpha := alpha + dst_alpha
+	return color.NRGBA{
+		uint8((uint32(src.R)*alpha + uint32(dst.R)*dst_alpha) / out_alpha),
+		uint8((uint32(src.G)*alpha + uint32(dst.G)*dst_alpha) / out_alpha),
+		uint8((uint32(src.B)*alpha + uint32(dst.B)*dst_alpha) / out_alpha),
+		uint8(out_alpha),
+	}
+}
+
+func nrgba_from_color(c gopi.Color) color.NRGBA {
+	// Returns color 0000 <= v <= FFFF
+	r, g, b, a := c.RGBA()
+	return color.NRGBA{uint8(r >> 8), uint8(g >> 8), uint8(b >> 8), uint8(a >> 8)}
+}
+
+// nrgba_from_image returns a pixel of an image as a color which is not
+// premultiplied, where premultiplied channels which are greater than
+// alpha are clamped rather than overflowing
+func nrgba_from_image(c color.Color) color.NRGBA {
+	if c_, ok := c.(color.NRGBA); ok {
+		return c_
+	}
+	r, g, b, a := c.RGBA()
+	if a == 0 {
+		return color.NRGBA{}
+	} else if a == 0xFFFF {
+		return color.NRGBA{uint8(r >> 8), uint8(g >> 8), uint8(b >> 8), 0xFF}
+	}
+	if r > a {
+		r = a
+	}
+	if g > a {
+		g = a
+	}
+	if b > a {
+		b = a
+	}
+	return color.NRGBA{uint8(r * 0xFFFF / a >> 8), uint8(g * 0xFFFF / a >> 8), uint8(b * 0xFFFF / a >> 8), uint8(a >> 8)}
+}
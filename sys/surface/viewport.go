@@ -0,0 +1,66 @@
+/*
+  Go Language Raspberry Pi Interface
+  (c) Copyright David Thorpe 2016-2019
+  All Rights Reserved
+
+  Documentation http://djthorpe.github.io/gopi/
+  For Licensing and Usage information, please see LICENSE.md
+*/
+
+package surface
+
+import (
+	"image"
+
+	// Frameworks
+	gopi "github.com/djthorpe/gopi"
+)
+
+////////////////////////////////////////////////////////////////////////////////
+// TYPES
+
+// SurfaceViewport is implemented by the surface manager in addition to
+// gopi.SurfaceManager, in order to draw part of a bitmap on a surface,
+// for sprite sheets and panning over large bitmaps
+type SurfaceViewport interface {
+	// Set the part of the bitmap which is drawn by a surface within an
+	// update. The viewport must fit within the bitmap, and is scaled to
+	// the size of the surface
+	SetViewport(s gopi.Surface, rect image.Rectangle) error
+
+	// Move the part of the bitmap which is drawn by a surface within an
+	// update, which must remain within the bitmap
+	MoveViewportBy(s gopi.Surface, delta gopi.Point) error
+
+	// Return the part of the bitmap which is drawn by a surface, or an
+	// empty rectangle if the surface does not draw a bitmap
+	ViewportForSurface(s gopi.Surface) image.Rectangle
+}
+
+////////////////////////////////////////////////////////////////////////////////
+// VIEWPORT
+
+func (this *manager) ViewportForSurface(s gopi.Surface) image.Rectangle {
+	if surface_, ok := s.(*surface); ok == false || surface_.native == nil || surface_.bitmap == nil {
+		return image.ZR
+	} else {
+		native := surface_.native
+		return image.Rect(int(native.src_origin.X), int(native.src_origin.Y), int(native.src_origin.X)+int(native.src_size.W), int(native.src_origin.Y)+int(native.src_size.H))
+	}
+}
+
+////////////////////////////////////////////////////////////////////////////////
+// PRIVATE METHODS
+
+// viewport_is_valid returns true if a viewport is not empty and fits
+// within a bitmap
+func viewport_is_valid(rect image.Rectangle, b gopi.Bitmap) bool {
+	size := b.Size()
+	if rect.Empty() {
+		return false
+	} else if rect.Dx() > 0xFFFF || rect.Dy() > 0xFFFF {
+		return false
+	} else {
+		return rect.In(image.Rect(0, 0, int(size.W), int(size.H)))
+	}
+}